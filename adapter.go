@@ -0,0 +1,206 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync/atomic"
+)
+
+// Adapter is implemented by pointers to types synthesized from registered services, e.g. *Lazy[Service].
+// Adapters aren't registered. When unregistered type implementing Adapter is requested
+// its zero value is adapted instead. Registered services take precedence over adapters.
+//
+// Example of a third party adapter:
+//
+//	type Provider[Service any] struct{ c ioc.Dic }
+//
+//	func (p *Provider[Service]) Adapt(c ioc.Dic) error {
+//	    p.c = c
+//	    return nil
+//	}
+//
+//	func (p Provider[Service]) Get() (Service, error) { return ioc.TryGet[Service](p.c) }
+type Adapter interface {
+	Adapt(c Dic) error
+}
+
+// Adapt sets getter of the service. Registered service is retrieved on the first call.
+// Services which aren't registered, e.g. resolved ones, are retrieved by Adapt
+// because adapters and resolvers cannot tell whether they provide the service without providing it
+func (l *Lazy[Service]) Adapt(c Dic) error {
+	key := typeKey[Service]()
+	if s, ok := c.lookup(key); !ok {
+		service, err := tryGet[Service](c, key, "")
+		if err != nil {
+			return err
+		}
+		*l = func() Service { return service }
+		return nil
+	} else if err := c.visible(key, s); err != nil {
+		return err
+	}
+	// getter is cached by container so it can be called concurrently
+	var created atomic.Pointer[Service]
+	*l = func() Service {
+		if service := created.Load(); service != nil {
+			return *service
+		}
+		// lazy getter is wired so it isn't reported by sealed container
+		service, err := tryGet[Service](c, key, "")
+		if err != nil {
+			throw(err)
+		}
+		created.Store(&service)
+		return service
+	}
+	return nil
+}
+
+// Adapt sets factory creating new instance of the registered service on every call.
+// Wraps are applied to every instance
+func (t *Transient[Service]) Adapt(c Dic) error {
+	key := typeKey[Service]()
+	s, err := c.registered(key)
+	if err != nil {
+		return err
+	}
+	*t = func() Service {
+		instance, err := c.transient(key, s)
+		if err != nil {
+			throw(err)
+		}
+		service, _ := instance.(Service)
+		return service
+	}
+	return nil
+}
+
+// transient creates new instance of the service. Retrievals of the creator are wiring like retrievals of other creators.
+// Returns ErrCircularDependency when service is requested during its own creation
+func (c Dic) transient(key serviceID, s service) (any, error) {
+	c, done := c.resolving()
+	if done != nil {
+		defer done()
+	}
+	r := c.resolution
+	c.c.creationMapMutex.Lock()
+	creating := *s.owner == r
+	c.c.creationMapMutex.Unlock()
+	if creating || slices.Contains(r.transients, key) {
+		return nil, errors.Join(
+			ErrCircularDependency,
+			fmt.Errorf("service of type '%s' is requested during its own creation", keyType(key).String()),
+		)
+	}
+	r.transients = append(r.transients, key)
+	defer func() { r.transients = r.transients[:len(r.transients)-1] }()
+
+	// instances keep container which is reported by sealed container after creator returns
+	var constructing atomic.Bool
+	constructing.Store(true)
+	instance, err := c.construct(key, s, &constructing)
+	constructing.Store(false)
+	if err != nil {
+		return nil, err
+	}
+	s.wraps(c.in(s.module).during(wiring), instance)
+	return instance, nil
+}
+
+// Adapt sets registered service. Optional is empty when service isn't registered
+func (o *Optional[Service]) Adapt(c Dic) error {
+	// only a missing service is absent, failures of provided services are returned
+	service, ok, err := find[Service](c, typeKey[Service](), "")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*o = Optional[Service]{}
+		return nil
+	}
+	*o = Optional[Service]{value: service, ok: true}
+	return nil
+}
+
+// registered returns registered service visible from the container
+func (c Dic) registered(key serviceID) (service, error) {
+	s, ok := c.lookup(key)
+	if !ok {
+		return s, errors.Join(
+			ErrServiceIsntRegistered,
+			fmt.Errorf("service of type '%s' is not registered", keyType(key).String()),
+		)
+	}
+	return s, c.visible(key, s)
+}
+
+// synthesize returns service of unregistered type.
+// Unnamed `func() T` and `[]T` are synthesized from services registered as T. Other types are resolved by resolvers
+func (c Dic) synthesize(key serviceID) (any, bool, error) {
+	t := keyType(key)
+	switch {
+	case t.Name() != "":
+	case t.Kind() == reflect.Func && t.NumIn() == 0 && t.NumOut() == 1 && !t.IsVariadic():
+		if _, ok := c.lookup(serviceKey(t.Out(0))); ok {
+			getter := c.getter(t)
+			c.storeAdapted(key, getter)
+			return getter, true, nil
+		}
+		// like Lazy getter of service which isn't registered, getter of unregistered service retrieves it now
+		service := reflect.New(t.Out(0))
+		if ok, err := c.find(service.Interface(), ""); err != nil {
+			return nil, true, err
+		} else if ok {
+			getter := reflect.MakeFunc(t, func([]reflect.Value) []reflect.Value { return []reflect.Value{service.Elem()} }).Interface()
+			c.storeAdapted(key, getter)
+			return getter, true, nil
+		}
+	case t.Kind() == reflect.Slice:
+		if services, ok, err := c.all(t); ok || err != nil {
+			return services, ok, err
+		}
+	}
+	return c.resolve(key)
+}
+
+// getter returns `func() T` retrieving registered T
+func (c Dic) getter(t reflect.Type) any {
+	return reflect.MakeFunc(t, func([]reflect.Value) []reflect.Value {
+		service := reflect.New(t.Out(0))
+		if err := c.injectNamed(service.Interface(), ""); err != nil {
+			throw(err)
+		}
+		return []reflect.Value{service.Elem()}
+	}).Interface()
+}
+
+// all returns `[]T` with unnamed and named services registered as T in registration order.
+// Returns false when T isn't registered
+func (c Dic) all(t reflect.Type) (any, bool, error) {
+	elem := serviceKey(t.Elem())
+	services := reflect.MakeSlice(t, 0, 0)
+	for i, key := range c.c.keys {
+		if named, ok := key.(namedServiceID); key != elem && (!ok || named.service != elem) {
+			continue
+		}
+		service := c.c.services[i]
+		if err := c.visible(key, service); err != nil {
+			return nil, false, err
+		}
+		instance, err := c.create(key, service)
+		if err != nil {
+			return nil, false, err
+		}
+		value := reflect.Zero(t.Elem())
+		if instance != nil {
+			value = reflect.ValueOf(instance)
+		}
+		services = reflect.Append(services, value)
+	}
+	if services.Len() == 0 {
+		return nil, false, nil
+	}
+	return services.Interface(), true, nil
+}
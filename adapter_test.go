@@ -0,0 +1,149 @@
+package ioc_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+// Provider is a third party adapter
+type Provider[Service any] struct{ c ioc.Dic }
+
+func (p *Provider[Service]) Adapt(c ioc.Dic) error {
+	p.c = c
+	return nil
+}
+
+func (p Provider[Service]) Get() Service { return ioc.Get[Service](p.c) }
+
+func TestAdapters(t *testing.T) {
+	type Handler interface{ Name() string }
+	type Counter struct{ N int }
+	type Services struct {
+		Lazy      ioc.Lazy[*Counter]      `inject:""`
+		Getter    func() *Counter         `inject:""`
+		Transient ioc.Transient[*Counter] `inject:""`
+		Optional  ioc.Optional[*Counter]  `inject:""`
+		Provider  Provider[*Counter]      `inject:""`
+		Handlers  []Handler               `inject:""`
+	}
+
+	created := 0
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) *Counter { created++; return &Counter{N: created} })
+		ioc.Register(b, func(c ioc.Dic) Handler { return namedHandler("default") })
+		ioc.RegisterNamed(b, "admin", func(c ioc.Dic) Handler { return namedHandler("admin") })
+	})
+	services := ioc.GetServices[Services](c)
+	counter := ioc.Get[*Counter](c)
+
+	if services.Lazy() != counter || services.Getter() != counter || services.Provider.Get() != counter {
+		t.Errorf("expected synthesized getters to return registered service")
+	}
+	if !services.Optional.Ok() || services.Optional.Value() != counter {
+		t.Errorf("expected synthesized optional to hold registered service")
+	}
+	if transient := services.Transient(); transient == counter || transient.N != 2 {
+		t.Errorf("expected synthesized transient to create new instance and got %v", transient)
+	}
+	if len(services.Handlers) != 2 || services.Handlers[0].Name() != "default" || services.Handlers[1].Name() != "admin" {
+		t.Errorf("expected slice of unnamed and named handlers and got %v", services.Handlers)
+	}
+
+	type Missing struct{}
+	if _, err := ioc.TryGet[ioc.Lazy[Missing]](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected lazy getter of unregistered service to be ErrServiceIsntRegistered and got %v", err)
+	}
+	if _, err := ioc.TryGet[[]Missing](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected slice of unregistered service to be ErrServiceIsntRegistered and got %v", err)
+	}
+}
+
+type namedHandler string
+
+func (h namedHandler) Name() string { return string(h) }
+
+func TestAdaptersAreCached(t *testing.T) {
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, 1)
+	})
+	ioc.Get[ioc.Lazy[int]](c)
+	ioc.Get[func() int](c)
+	if allocs := testing.AllocsPerRun(100, func() {
+		ioc.Get[ioc.Lazy[int]](c)
+		ioc.Get[func() int](c)
+	}); allocs != 0 {
+		t.Errorf("expected retrieval of cached adapters not to allocate and got %v allocations", allocs)
+	}
+}
+
+func TestTransientCircularDependency(t *testing.T) {
+	type Node struct{}
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Node {
+			ioc.Get[ioc.Transient[Node]](c)()
+			return Node{}
+		})
+	})
+
+	if _, err := ioc.TryGet[Node](c); !errors.Is(err, ioc.ErrCircularDependency) {
+		t.Errorf("expected ErrCircularDependency and got %v", err)
+	}
+	if _, err := ioc.TryGet[ioc.Transient[Node]](c); err != nil {
+		t.Fatalf("expected transient and got %v", err)
+	}
+	var err error
+	func() {
+		defer ioc.Recover(&err)
+		ioc.Get[ioc.Transient[Node]](c)()
+	}()
+	if !errors.Is(err, ioc.ErrCircularDependency) {
+		t.Errorf("expected ErrCircularDependency from transient and got %v", err)
+	}
+}
+
+func TestLazyParallel(t *testing.T) {
+	type B struct{}
+	type A struct{ B B }
+
+	retrieved := make(chan struct{})
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) A {
+			lazy := ioc.Get[ioc.Lazy[B]](c)
+			close(retrieved)
+			return A{B: lazy()}
+		})
+		ioc.Register(b, func(c ioc.Dic) B {
+			time.Sleep(time.Millisecond)
+			return B{}
+		})
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := ioc.TryGet[A](c); err != nil {
+			t.Errorf("expected service and got %v", err)
+		}
+	}()
+	<-retrieved
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			defer func() {
+				if err != nil {
+					t.Errorf("expected lazy service and got %v", err)
+				}
+			}()
+			defer ioc.Recover(&err)
+			ioc.Get[ioc.Lazy[B]](c)()
+		}()
+	}
+	wg.Wait()
+}
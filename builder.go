@@ -1,19 +1,46 @@
 package ioc
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"reflect"
+	"slices"
 	"sync"
+	"unsafe"
 )
 
-var Registry []Pkg
-
+// serviceID is reflect.Type of the service or namedServiceID.
+// Types are unique non-nil pointers so they hash well unlike typed nil pointers which all hash the same
 type serviceID any
 
 type builder struct {
-	wraps           map[serviceID][]ctorWrap
-	services        map[serviceID]service
-	servicesOrdered []serviceID
+	wraps map[serviceID][]ctorWrap
+	// index is a position of the service in services and keys.
+	// It is built by indexed once all packages are registered so it is sized to the number of services
+	index map[serviceID]int
+	// services and their keys in registration order
+	services []service
+	keys     []serviceID
+	modules  map[string]Module
+	// configured are packages created by NewPkgT which are included
+	configured []configuredPkg
+	// module is a name of the module which is being included
+	module string
+	// profiled are modules included after all packages when their profile is active
+	profiled []Module
+	profiles []string
+	// overrides are applied after all packages
+	overrides []func()
+	// defaults are applied after overrides
+	defaults []func()
+
+	injectUnexported bool
+	retryFailed      bool
+	lazy             bool
+	resolvers        []Resolver
+	generics         map[string]generic
+	seal             bool
+	sealPolicy       SealPolicy
 }
 
 type Builder struct {
@@ -21,97 +48,271 @@ type Builder struct {
 }
 
 func NewContainer(pkgs ...Pkg) Dic {
-	b := Builder{
-		b: &builder{
-			wraps:    map[serviceID][]ctorWrap{},
-			services: map[serviceID]service{},
-		},
-	}
-	registered := map[uintptr]struct{}{}
+	b := Builder{b: &builder{}}
+	// there are few packages so slice is cheaper than a map
+	registered := make([]unsafe.Pointer, 0, len(pkgs))
 	for _, pkg := range pkgs {
-		k := reflect.ValueOf(pkg).Pointer()
-		if _, ok := registered[k]; ok {
+		k := pkgID(pkg)
+		if slices.Contains(registered, k) {
 			continue
 		}
-		registered[k] = struct{}{}
+		registered = append(registered, k)
 		pkg(b)
 	}
+	b.includeProfiled()
+	b.indexed()
+	for _, override := range b.b.overrides {
+		override()
+	}
+	for _, registerDefault := range b.b.defaults {
+		registerDefault()
+	}
 	return b.build()
 }
 
+// TryNewContainer works like NewContainer but returns *Error instead of panicking
+func TryNewContainer(pkgs ...Pkg) (c Dic, err error) {
+	defer Recover(&err)
+	return NewContainer(pkgs...), nil
+}
+
+// pkgID identifies package value.
+// Copies of the same package share id and closures created by the same function (e.g. NewPkgT) don't.
+// Packages created by NewPkgT are deduplicated by their config when they are called instead
+func pkgID(pkg Pkg) unsafe.Pointer {
+	return funcID(pkg)
+}
+
+// funcID identifies function value the same way as pkgID
+func funcID[Func any](fn Func) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&fn))
+}
+
+// configuredPkg is a package created by NewPkgT
+type configuredPkg struct {
+	registerID unsafe.Pointer
+	config     any
+}
+
+// includedPkgT reports whether package created by the register function with equal config is included
+// and records the package otherwise
+func (b Builder) includedPkgT(registerID unsafe.Pointer, config any) bool {
+	for _, pkg := range b.b.configured {
+		if pkg.registerID == registerID && sameConfig(reflect.ValueOf(pkg.config), reflect.ValueOf(config), map[configVisit]bool{}) {
+			return true
+		}
+	}
+	b.b.configured = append(b.b.configured, configuredPkg{registerID: registerID, config: config})
+	return false
+}
+
 func (b Builder) build() Dic {
 	services := b.b.services
-	for key, service := range services {
-		wraps, ok := b.b.wraps[key]
-		if !ok || len(wraps) == 0 {
+	// only wrapped services are replaced so build doesn't rewrite every service.
+	// wraps of unregistered services are kept for instances produced by factories
+	var composed map[serviceID]func(Dic, any)
+	for key, wraps := range b.b.wraps {
+		if len(wraps) == 0 {
 			continue
 		}
-		ctor := service.creator
-		w := []ctorWrap(wraps)
-		service.creator = func(d Dic) any {
-			s := ctor(d)
-			return s
-		}
-		service.wraps = func(d Dic, s any) {
-			for _, wrap := range w {
-				wrap.wraps(d, s)
+		wrap := func(d Dic, s any) {
+			for _, wrap := range wraps {
+				wrap.wraps(d.in(wrap.module), s)
 			}
 		}
-		services[key] = service
+		if composed == nil {
+			composed = map[serviceID]func(Dic, any){}
+		}
+		composed[key] = wrap
+		if i, ok := b.b.index[key]; ok {
+			services[i].wraps = wrap
+		}
+	}
+	resolvers := b.b.resolvers
+	if len(b.b.generics) != 0 {
+		// generic registrations take precedence over resolvers
+		resolvers = append([]Resolver{genericResolver(b.b.generics)}, resolvers...)
 	}
 	c := Dic{
 		c: &dic{
-			serviceRegisterMutex: &sync.Mutex{},
-			services:             services,
+			index:    b.b.index,
+			services: services,
+			keys:     b.b.keys,
+			wraps:    composed,
 
 			creationMapMutex: sync.Mutex{},
-			creationMap:      make(map[serviceID]struct{}),
+			created:          make([]serviceID, 0, len(services)),
+
+			injectUnexported: b.b.injectUnexported,
+			retryFailed:      b.b.retryFailed,
+
+			resolvers: resolvers,
+
+			sealPolicy: b.b.sealPolicy,
 		},
 	}
-	for _, key := range b.b.servicesOrdered {
-		service := b.b.services[key]
-		if *service.instance != nil {
-			continue
+	c.c.creationDone.L = &c.c.creationMapMutex
+	if len(resolvers) != 0 {
+		c.c.resolved = map[serviceID]service{}
+	}
+	if !b.b.lazy {
+		// services are created in one resolution instead of a resolution per service
+		building, done := c.resolving()
+		defer done()
+		for i, service := range services {
+			if service.created() {
+				continue
+			}
+			if _, err := building.create(b.b.keys[i], service); err != nil {
+				throw(err)
+			}
 		}
-		c.tryLock(key)
-		instance := service.creator(c)
-		*service.instance = instance
-		b.b.services[key] = service
-		c.unlock(key)
-		service.wraps(c, instance)
+	}
+	if b.b.seal {
+		c.Seal()
 	}
 	return c
 }
 
-// registers service and its lazy getter with singleton lifetimes
+// registers service with singleton lifetime
 func Register[Service any](b Builder, creator func(c Dic) Service) {
-	key := typeKey[Service]()
-	if _, ok := b.b.services[key]; ok {
-		log.Panicf("registered service already exists '%s'", reflect.TypeFor[Service]().String())
+	register[Service](b, newService(func(c Dic) any { return creator(c) }))
+}
+
+// registers service with singleton lifetime only when cond is true
+func RegisterIf[Service any](b Builder, cond bool, creator func(c Dic) Service) {
+	if cond {
+		Register(b, creator)
 	}
+}
 
-	lazyKey := typeKey[Lazy[Service]]()
-	if _, ok := b.b.services[lazyKey]; ok {
-		log.Panicf("registered service already exists '%s'", reflect.TypeFor[Service]().String())
+// registers service with singleton lifetime.
+// private service can be resolved only by services and wraps registered by the same module
+func RegisterPrivate[Service any](b Builder, creator func(c Dic) Service) {
+	if b.b.module == "" {
+		throw(errors.Join(
+			ErrInvalidServiceRegistration,
+			fmt.Errorf("private service of type '%s' has to be registered by a module", reflect.TypeFor[Service]().String()),
+		))
 	}
+	s := newService(func(c Dic) any { return creator(c) })
+	s.private = true
+	register[Service](b, s)
+}
 
-	b.b.services[key] = newService(func(c Dic) any { return creator(c) })
+// replaces service registered by any package. Overrides are applied after all packages so package order doesn't matter.
+// Overridden service keeps its module visibility and wraps. Last override wins.
+// When service isn't registered it is registered
+func Override[Service any](b Builder, creator func(c Dic) Service) {
+	module := b.b.module
+	b.b.overrides = append(b.b.overrides, func() {
+		key := typeKey[Service]()
+		s := newService(func(c Dic) any { return creator(c) })
+		i, ok := b.b.index[key]
+		if !ok {
+			b.b.module = module
+			register[Service](b, s)
+			b.b.module = ""
+			return
+		}
+		s.module = b.b.services[i].module
+		s.private = b.b.services[i].private
+		b.b.services[i] = s
+	})
+}
 
-	b.b.services[lazyKey] = newService(func(c Dic) any {
-		var service Service
-		ok := false
-		var lazy Lazy[Service] = func() Service {
-			if ok {
-				return service
-			}
-			service = Get[Service](c)
-			ok = true
-			return service
+// registers service with singleton lifetime only when no package or override registers it.
+// Defaults are applied after overrides. First default wins
+func RegisterDefault[Service any](b Builder, creator func(c Dic) Service) {
+	module := b.b.module
+	b.b.defaults = append(b.b.defaults, func() {
+		if _, ok := b.b.index[typeKey[Service]()]; ok {
+			return
 		}
-		return lazy
+		b.b.module = module
+		register[Service](b, newService(func(c Dic) any { return creator(c) }))
+		b.b.module = ""
 	})
+}
+
+// registers already created service with singleton lifetime.
+// value can be nil (nil interface or nil pointer) and it is still treated as created
+func Provide[Service any](b Builder, value Service) {
+	register[Service](b, newService(func(c Dic) any { return value }))
+}
+
+// registers service under a name with singleton lifetime.
+// named services can be retrieved with GetNamed or injected with `inject:"name=x"` tag.
+// wraps aren't applied to named services
+func RegisterNamed[Service any](b Builder, name string, creator func(c Dic) Service) {
+	if name == "" {
+		throw(errors.Join(
+			ErrInvalidServiceRegistration,
+			fmt.Errorf("service of type '%s' cannot be registered with empty name", reflect.TypeFor[Service]().String()),
+		))
+	}
+	b.register(namedKey(typeKey[Service](), name), newService(func(c Dic) any { return creator(c) }))
+}
+
+// registers already created services under their dynamic types with singleton lifetimes.
+// untyped nil cannot be supplied because it has no type, use Provide instead
+func Supply(b Builder, values ...any) {
+	for _, value := range values {
+		if value == nil {
+			throw(errors.Join(
+				ErrInvalidServiceRegistration,
+				fmt.Errorf("cannot supply untyped nil, use Provide to register nil service"),
+			))
+		}
+		serviceType := reflect.TypeOf(value)
+		b.register(serviceKey(serviceType), newService(func(c Dic) any { return value }))
+	}
+}
+
+func register[Service any](b Builder, s service) Key[Service] {
+	key := Key[Service]{index: len(b.b.services), id: typeKey[Service]()}
+	b.register(key.id, s)
+
+	return key
+}
 
-	b.b.servicesOrdered = append(b.b.servicesOrdered, key, lazyKey)
+func (b Builder) register(key serviceID, s service) {
+	// duplicates registered by packages are found by indexed
+	if b.b.index != nil {
+		if _, ok := b.b.index[key]; ok {
+			throw(alreadyRegistered(key))
+		}
+		b.b.index[key] = len(b.b.services)
+	}
+	s.module = b.b.module
+	b.b.services = append(b.b.services, s)
+	b.b.keys = append(b.b.keys, key)
+}
+
+// indexed builds index of services registered by packages
+func (b Builder) indexed() {
+	if b.b.index != nil {
+		return
+	}
+	index := make(map[serviceID]int, len(b.b.keys))
+	for i, key := range b.b.keys {
+		if _, ok := index[key]; ok {
+			throw(alreadyRegistered(key))
+		}
+		index[key] = i
+	}
+	b.b.index = index
+}
+
+func alreadyRegistered(key serviceID) error {
+	name := ""
+	if named, ok := key.(namedServiceID); ok {
+		name = named.name
+	}
+	return errors.Join(
+		ErrServiceAlreadyRegistered,
+		fmt.Errorf("registered service already exists '%s'%s", keyType(key).String(), nameSuffix(name)),
+	)
 }
 
 // wraps are applied in addition order after service initialization.
@@ -119,10 +320,29 @@ func Register[Service any](b Builder, creator func(c Dic) Service) {
 func Wrap[Service any](b Builder, wrap func(c Dic, s Service)) {
 	key := typeKey[Service]()
 	wraps := newCtorWrap(wrap)
+	wraps.module = b.b.module
 
-	if _, ok := b.b.wraps[key]; !ok {
-		b.b.wraps[key] = make([]ctorWrap, 0, 1)
+	if b.b.wraps == nil {
+		b.b.wraps = map[serviceID][]ctorWrap{}
 	}
-
 	b.b.wraps[key] = append(b.b.wraps[key], wraps)
 }
+
+// InjectUnexportedFields is an option which allows InjectServices to inject unexported fields with `inject` tag.
+// Options are packages so they are passed to NewContainer along with other packages
+func InjectUnexportedFields(b Builder) {
+	b.b.injectUnexported = true
+}
+
+// LazyConstruction is an option which makes container create services on the first retrieval
+// instead of creating all of them in NewContainer.
+// Retrievals of service which is being created by other goroutine wait until it is created
+func LazyConstruction(b Builder) {
+	b.b.lazy = true
+}
+
+// RetryFailedConstruction is an option which makes container call creator again
+// on the next retrieval after it panicked. By default failure is cached and returned on every retrieval
+func RetryFailedConstruction(b Builder) {
+	b.b.retryFailed = true
+}
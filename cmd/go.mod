@@ -0,0 +1,16 @@
+module github.com/ogiusek/ioc/v2/cmd
+
+go 1.24.0
+
+require (
+	github.com/ogiusek/ioc/v2 v2.0.0-00010101000000-000000000000
+	golang.org/x/tools v0.38.0
+)
+
+require (
+	golang.org/x/mod v0.29.0 // indirect
+	golang.org/x/sync v0.17.0 // indirect
+)
+
+// tools are developed with ioc of the repository
+replace github.com/ogiusek/ioc/v2 => ../
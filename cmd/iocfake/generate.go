@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const ioctestPath = "github.com/ogiusek/ioc/v2/ioctest"
+
+// file is a generated file with its imports
+type file struct {
+	pkg     *types.Package
+	imports map[string]string // path to name
+	names   map[string]string // name to path
+}
+
+// generate returns source of the file of the package in dir with fakes of the interfaces declared in the package in dir.
+// Interfaces are declared by package pattern instead when it isn't empty, e.g. so fakes of interfaces
+// declared by one package are generated into a package imported by tests of other packages
+func generate(dir, pattern string, typeNames []string) ([]byte, error) {
+	out, err := load(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+	pkg := out
+	if pattern != "" {
+		if pkg, err = load(dir, pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &file{
+		pkg:     out,
+		imports: map[string]string{},
+		names:   map[string]string{out.Name(): out.Path()},
+	}
+	var body bytes.Buffer
+	for _, typeName := range typeNames {
+		obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type '%s' isn't declared in '%s'", typeName, pkg.Path())
+		}
+		if pkg != out && !obj.Exported() {
+			return nil, fmt.Errorf("type '%s' isn't exported by '%s'", typeName, pkg.Path())
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() != 0 {
+			return nil, fmt.Errorf("type '%s' has to be a non generic named interface", typeName)
+		}
+		iface, ok := named.Underlying().(*types.Interface)
+		if !ok {
+			return nil, fmt.Errorf("type '%s' isn't an interface", typeName)
+		}
+		if !iface.IsMethodSet() {
+			return nil, fmt.Errorf("type '%s' is a constraint and cannot be faked", typeName)
+		}
+		f.emitFake(&body, typeName, types.TypeString(named, f.qualifier), iface)
+	}
+
+	ioctestName := f.qualifier(types.NewPackage(ioctestPath, "ioctest"))
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by iocfake. DO NOT EDIT.\n\npackage %s\n\nimport (\n", out.Name())
+	for _, path := range slices.Sorted(maps.Keys(f.imports)) {
+		fmt.Fprintf(&src, "\t%s %q\n", f.imports[path], path)
+	}
+	fmt.Fprintf(&src, ")\n\n")
+	src.Write(body.Bytes())
+
+	fmt.Fprintf(&src, "func init() {\n")
+	for _, typeName := range typeNames {
+		iface := types.TypeString(pkg.Scope().Lookup(typeName).Type(), f.qualifier)
+		fmt.Fprintf(&src, "\t%s.RegisterFake(func() %s { return &%sFake{} })\n", ioctestName, iface, typeName)
+	}
+	fmt.Fprintf(&src, "}\n")
+	return format.Source(src.Bytes())
+}
+
+// load returns types of the package matching pattern relative to dir
+func load(dir, pattern string) (*types.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package matching '%s' in '%s', got %d", pattern, dir, len(pkgs))
+	}
+	for _, err := range pkgs[0].Errors {
+		return nil, err
+	}
+	return pkgs[0].Types, nil
+}
+
+// qualifier names packages and records imports
+func (f *file) qualifier(pkg *types.Package) string {
+	if pkg.Path() == f.pkg.Path() {
+		return ""
+	}
+	if name, ok := f.imports[pkg.Path()]; ok {
+		return name
+	}
+	name := pkg.Name()
+	for i := 2; ; i++ {
+		if _, ok := f.names[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s%d", pkg.Name(), i)
+	}
+	f.imports[pkg.Path()] = name
+	f.names[name] = pkg.Path()
+	return name
+}
+
+// emitFake writes fake of the interface. name is the interface name qualified for the file
+func (f *file) emitFake(body *bytes.Buffer, typeName, name string, iface *types.Interface) {
+	ioctestName := f.qualifier(types.NewPackage(ioctestPath, "ioctest"))
+	fake := typeName + "Fake"
+
+	fmt.Fprintf(body, "// %s is a recording fake of %s.\n", fake, name)
+	fmt.Fprintf(body, "// Methods call <Method>Func when it is set and return zero values otherwise\n")
+	fmt.Fprintf(body, "type %s struct {\n\t%s.Fake\n", fake, ioctestName)
+	for method := range iface.Methods() {
+		fmt.Fprintf(body, "\t%sFunc func%s\n", method.Name(), strings.TrimPrefix(types.TypeString(method.Signature(), f.qualifier), "func"))
+	}
+	fmt.Fprintf(body, "}\n\n")
+
+	for method := range iface.Methods() {
+		sig := method.Signature()
+		params := make([]string, sig.Params().Len())
+		args := make([]string, sig.Params().Len())
+		for i := range sig.Params().Len() {
+			t := sig.Params().At(i).Type()
+			typ := types.TypeString(t, f.qualifier)
+			args[i] = fmt.Sprintf("p%d", i)
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				typ = "..." + types.TypeString(t.(*types.Slice).Elem(), f.qualifier)
+			}
+			params[i] = fmt.Sprintf("p%d %s", i, typ)
+		}
+		callArgs := slices.Clone(args)
+		if sig.Variadic() {
+			callArgs[len(callArgs)-1] += "..."
+		}
+		results := make([]string, sig.Results().Len())
+		for i := range sig.Results().Len() {
+			results[i] = types.TypeString(sig.Results().At(i).Type(), f.qualifier)
+		}
+		resultList := strings.Join(results, ", ")
+		if len(results) > 1 {
+			resultList = "(" + resultList + ")"
+		}
+
+		fmt.Fprintf(body, "func (f *%s) %s(%s) %s {\n", fake, method.Name(), strings.Join(params, ", "), resultList)
+		// Fake is selected explicitly because interface can have a Record method
+		fmt.Fprintf(body, "\tf.Fake.Record(%s)\n", strings.Join(append([]string{fmt.Sprintf("%q", method.Name())}, args...), ", "))
+		call := fmt.Sprintf("f.%sFunc(%s)", method.Name(), strings.Join(callArgs, ", "))
+		fmt.Fprintf(body, "\tif f.%sFunc != nil {\n", method.Name())
+		if len(results) == 0 {
+			fmt.Fprintf(body, "\t\t%s\n\t}\n}\n\n", call)
+			continue
+		}
+		fmt.Fprintf(body, "\t\treturn %s\n\t}\n", call)
+		zeros := make([]string, len(results))
+		for i, result := range results {
+			zeros[i] = fmt.Sprintf("r%d", i)
+			fmt.Fprintf(body, "\tvar r%d %s\n", i, result)
+		}
+		fmt.Fprintf(body, "\treturn %s\n}\n\n", strings.Join(zeros, ", "))
+	}
+}
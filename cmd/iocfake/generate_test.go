@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("testdata", "app")
+	src, err := generate(dir, "", []string{"Mailer", "Clock", "Logger", "Store"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "ioc_fakes_test.go")
+	golden, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Errorf("generated code differs from %s, regenerate it with `go run . -dir testdata/app -type Mailer,Clock,Logger,Store`\n%s", output, src)
+	}
+}
+
+func TestGenerateNotInterface(t *testing.T) {
+	if _, err := generate(filepath.Join("testdata", "app"), "", []string{"Missing"}); err == nil {
+		t.Errorf("expected error for undeclared type")
+	}
+}
+
+func TestGenerateExternalPackage(t *testing.T) {
+	dir := filepath.Join("testdata", "appfake")
+	src, err := generate(dir, "../app", []string{"Mailer", "Store"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "fakes.go")
+	golden, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Errorf("generated code differs from %s, regenerate it with `go run . -dir testdata/appfake -pkg ../app -type Mailer,Store -out fakes.go`\n%s", output, src)
+	}
+}
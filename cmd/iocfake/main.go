@@ -0,0 +1,43 @@
+// iocfake generates recording fakes of interfaces for ioctest.WithFakes.
+//
+// Every fake embeds ioctest.Fake recording its calls and has a <Method>Func field
+// called when it is set. Unconfigured methods return zero values.
+// Fakes register themselves with ioctest.RegisterFake in init so they are generated into a test file.
+// Fakes of interfaces declared by other package are generated with -pkg into a package
+// which tests of other packages import for side effects.
+//
+// Usage:
+//
+//	//go:generate go run github.com/ogiusek/ioc/v2/cmd/iocfake -type Mailer,Clock
+//	//go:generate go run github.com/ogiusek/ioc/v2/cmd/iocfake -pkg example.com/app/mail -type Mailer -out fakes.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package with interfaces")
+	out := flag.String("out", "ioc_fakes_test.go", "output file name relative to the package directory")
+	typeNames := flag.String("type", "", "comma separated names of interfaces")
+	pkg := flag.String("pkg", "", "package declaring the interfaces when it isn't the package in -dir")
+	flag.Parse()
+
+	if *typeNames == "" {
+		fmt.Fprintf(os.Stderr, "iocfake: -type is required\n")
+		os.Exit(2)
+	}
+	src, err := generate(*dir, *pkg, strings.Split(*typeNames, ","))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "iocfake: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *out), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "iocfake: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,22 @@
+package app
+
+import "time"
+
+type Mailer interface {
+	Send(to string, body string) error
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Logger interface {
+	Log(format string, args ...any)
+}
+
+// Store embeds interfaces and has multiple results
+type Store interface {
+	Clock
+	Get(key string) ([]byte, bool)
+	Record(key string)
+}
@@ -0,0 +1,2 @@
+// Package appfake has fakes of app interfaces imported by tests of packages using them
+package appfake
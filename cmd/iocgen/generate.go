@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const iocPath = "github.com/ogiusek/ioc/v2"
+
+// provider is a ctor registered with ioc.RegisterCtor
+type provider struct {
+	fn       *types.Func
+	service  types.Type
+	params   []types.Type
+	hasError bool
+	field    string
+}
+
+// graph is a package with its providers
+type graph struct {
+	pkg       *types.Package
+	providers []*provider
+	byType    map[string]*provider
+	imports   map[string]string // path to name
+	names     map[string]string // name to path
+}
+
+// generate returns source of the file with wiring of the package in dir.
+// output file is skipped while looking for providers because it can be stale
+func generate(dir, output, typeName string) ([]byte, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in '%s', got %d", dir, len(pkgs))
+	}
+	pkg := pkgs[0]
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range pkg.Errors {
+		if !strings.HasPrefix(err.Pos, output) {
+			return nil, err
+		}
+	}
+
+	g := &graph{
+		pkg:     pkg.Types,
+		byType:  map[string]*provider{},
+		imports: map[string]string{},
+		names:   map[string]string{pkg.Types.Name(): pkg.Types.Path()},
+	}
+	for i, file := range pkg.Syntax {
+		if i < len(pkg.CompiledGoFiles) && pkg.CompiledGoFiles[i] == output {
+			continue
+		}
+		if err := g.collect(pkg, file); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.providers) == 0 {
+		return nil, fmt.Errorf("no ioc.RegisterCtor calls found in '%s'", pkg.PkgPath)
+	}
+
+	ordered, err := g.sort()
+	if err != nil {
+		return nil, err
+	}
+	return g.emit(ordered, typeName)
+}
+
+// collect finds ioc.RegisterCtor calls in the file
+func (g *graph) collect(pkg *packages.Package, file *ast.File) error {
+	var err error
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || err != nil || !isRegisterCtor(pkg.TypesInfo, call.Fun) || len(call.Args) != 2 {
+			return err == nil
+		}
+		fn, ok := usedFunc(pkg.TypesInfo, call.Args[1])
+		if !ok {
+			err = fmt.Errorf("%s: ctor passed to ioc.RegisterCtor has to be a named function", pkg.Fset.Position(call.Pos()))
+			return false
+		}
+		err = g.add(fn)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", pkg.Fset.Position(call.Pos()), err)
+		}
+		return false
+	})
+	return err
+}
+
+func isRegisterCtor(info *types.Info, expr ast.Expr) bool {
+	fn, ok := usedFunc(info, expr)
+	return ok && fn.Name() == "RegisterCtor" && fn.Pkg() != nil && fn.Pkg().Path() == iocPath
+}
+
+func usedFunc(info *types.Info, expr ast.Expr) (*types.Func, bool) {
+	var ident *ast.Ident
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return nil, false
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok || fn.Signature().Recv() != nil {
+		return nil, false
+	}
+	return fn, true
+}
+
+func (g *graph) add(fn *types.Func) error {
+	sig := fn.Signature()
+	if sig.Variadic() {
+		return fmt.Errorf("ctor '%s' cannot be variadic", fn.Name())
+	}
+	results := sig.Results()
+	p := &provider{fn: fn}
+	switch {
+	case results.Len() == 1:
+	case results.Len() == 2 && types.Identical(results.At(1).Type(), types.Universe.Lookup("error").Type()):
+		p.hasError = true
+	default:
+		return fmt.Errorf("ctor '%s' has to return service or service and error", fn.Name())
+	}
+	p.service = results.At(0).Type()
+	if embedsIoc(p.service, "Out") {
+		return fmt.Errorf("ctor '%s' returns result struct embedding ioc.Out which isn't supported, register it with ioc.RegisterCtor without generating", fn.Name())
+	}
+	for i := range sig.Params().Len() {
+		param := sig.Params().At(i).Type()
+		if embedsIoc(param, "In") {
+			return fmt.Errorf("ctor '%s' takes parameter struct embedding ioc.In which isn't supported, register it with ioc.RegisterCtor without generating", fn.Name())
+		}
+		p.params = append(p.params, param)
+	}
+
+	key := types.TypeString(p.service, nil)
+	if _, ok := g.byType[key]; ok {
+		return fmt.Errorf("service '%s' is provided twice", key)
+	}
+	g.byType[key] = p
+	g.providers = append(g.providers, p)
+	return nil
+}
+
+// embedsIoc reports whether t is a struct embedding ioc type with the name, e.g. ioc.Out result struct
+func embedsIoc(t types.Type, name string) bool {
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := range structType.NumFields() {
+		field := structType.Field(i)
+		named, ok := types.Unalias(field.Type()).(*types.Named)
+		if ok && field.Embedded() && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == iocPath && named.Obj().Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sort returns providers in dependency order
+func (g *graph) sort() ([]*provider, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[*provider]int{}
+	var ordered []*provider
+	var visit func(p *provider, path []string) error
+	visit = func(p *provider, path []string) error {
+		path = append(path, types.TypeString(p.service, nil))
+		switch state[p] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependency %s", strings.Join(path, " -> "))
+		}
+		state[p] = visiting
+		for _, param := range p.params {
+			if dep, ok := g.byType[types.TypeString(param, nil)]; ok {
+				if err := visit(dep, path); err != nil {
+					return err
+				}
+			}
+		}
+		state[p] = visited
+		ordered = append(ordered, p)
+		return nil
+	}
+	for _, p := range g.providers {
+		if err := visit(p, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// qualifier names packages and records imports
+func (g *graph) qualifier(pkg *types.Package) string {
+	if pkg.Path() == g.pkg.Path() {
+		return ""
+	}
+	if name, ok := g.imports[pkg.Path()]; ok {
+		return name
+	}
+	name := pkg.Name()
+	for i := 2; ; i++ {
+		if _, ok := g.names[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s%d", pkg.Name(), i)
+	}
+	g.imports[pkg.Path()] = name
+	g.names[name] = pkg.Path()
+	return name
+}
+
+// fieldName returns exported identifier describing the type.
+// Types from other packages are prefixed with package name
+func (g *graph) fieldName(t types.Type, taken map[string]bool) string {
+	qualifier := func(pkg *types.Package) string {
+		if pkg.Path() == g.pkg.Path() {
+			return ""
+		}
+		return pkg.Name()
+	}
+	var name strings.Builder
+	for _, part := range strings.FieldsFunc(types.TypeString(t, qualifier), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		name.WriteString(string(runes))
+	}
+	res := name.String()
+	if res == "" || !unicode.IsLetter([]rune(res)[0]) {
+		res = "S" + res
+	}
+	for i := 2; taken[res]; i++ {
+		res = fmt.Sprintf("%s%d", name.String(), i)
+	}
+	taken[res] = true
+	return res
+}
+
+func (g *graph) emit(ordered []*provider, typeName string) ([]byte, error) {
+	iocName := g.qualifier(types.NewPackage(iocPath, "ioc"))
+
+	// methods of the graph cannot be used as fields
+	taken := map[string]bool{"Pkg": true, "Dic": true}
+	for _, p := range ordered {
+		p.field = g.fieldName(p.service, taken)
+	}
+
+	var body bytes.Buffer
+	var missing []string
+	fmt.Fprintf(&body, "// %s holds services registered with %s.RegisterCtor\n", typeName, iocName)
+	fmt.Fprintf(&body, "type %s struct {\n", typeName)
+	for _, p := range ordered {
+		fmt.Fprintf(&body, "\t%s %s\n", p.field, types.TypeString(p.service, g.qualifier))
+	}
+	fmt.Fprintf(&body, "}\n\n")
+
+	fmt.Fprintf(&body, "// New%s constructs services in dependency order\n", typeName)
+	fmt.Fprintf(&body, "func New%s() (*%s, error) {\n", typeName, typeName)
+	fmt.Fprintf(&body, "\tg := &%s{}\n", typeName)
+	if hasErrors(ordered) {
+		fmt.Fprintf(&body, "\tvar err error\n")
+	}
+	for _, p := range ordered {
+		args := make([]string, len(p.params))
+		for i, param := range p.params {
+			if dep, ok := g.byType[types.TypeString(param, nil)]; ok {
+				args[i] = "g." + dep.field
+				continue
+			}
+			// undefined identifier makes missing provider a compile time error
+			args[i] = "iocgenMissingProviderFor" + g.fieldName(param, map[string]bool{})
+			missing = append(missing, types.TypeString(param, nil))
+		}
+		call := fmt.Sprintf("%s(%s)", funcName(p.fn, g.qualifier), strings.Join(args, ", "))
+		if p.hasError {
+			fmt.Fprintf(&body, "\tif g.%s, err = %s; err != nil {\n\t\treturn nil, err\n\t}\n", p.field, call)
+			continue
+		}
+		fmt.Fprintf(&body, "\tg.%s = %s\n", p.field, call)
+	}
+	fmt.Fprintf(&body, "\treturn g, nil\n}\n\n")
+
+	fmt.Fprintf(&body, "// Pkg registers constructed services so they can be retrieved with %s.Get and %s.GetServices\n", iocName, iocName)
+	fmt.Fprintf(&body, "func (g *%s) Pkg() %s.Pkg {\n\treturn func(b %s.Builder) {\n", typeName, iocName, iocName)
+	for _, p := range ordered {
+		fmt.Fprintf(&body, "\t\t%s.Provide(b, g.%s)\n", iocName, p.field)
+	}
+	fmt.Fprintf(&body, "\t}\n}\n\n")
+
+	fmt.Fprintf(&body, "// Dic returns container with constructed services\n")
+	fmt.Fprintf(&body, "func (g *%s) Dic() %s.Dic {\n\treturn %s.NewContainer(g.Pkg())\n}\n", typeName, iocName, iocName)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by iocgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg.Name())
+	for _, path := range slices.Sorted(maps.Keys(g.imports)) {
+		fmt.Fprintf(&src, "\t%s %q\n", g.imports[path], path)
+	}
+	fmt.Fprintf(&src, ")\n\n")
+	src.Write(body.Bytes())
+
+	for _, m := range missing {
+		fmt.Fprintf(os.Stderr, "iocgen: missing provider for '%s'\n", m)
+	}
+	return format.Source(src.Bytes())
+}
+
+func hasErrors(providers []*provider) bool {
+	for _, p := range providers {
+		if p.hasError {
+			return true
+		}
+	}
+	return false
+}
+
+func funcName(fn *types.Func, qualifier types.Qualifier) string {
+	if name := qualifier(fn.Pkg()); name != "" {
+		return name + "." + fn.Name()
+	}
+	return fn.Name()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	output := filepath.Join("testdata", "app", "ioc_gen.go")
+	src, err := generate(filepath.Join("testdata", "app"), output, "Graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Errorf("generated code differs from %s, regenerate it with `go run . -dir testdata/app`\n%s", output, src)
+	}
+}
+
+func TestGenerateMissingProvider(t *testing.T) {
+	dir := filepath.Join("testdata", "missing")
+	src, err := generate(dir, filepath.Join(dir, "ioc_gen.go"), "Graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "g.Service = NewService(iocgenMissingProviderForConfig)") {
+		t.Errorf("expected missing provider to be an undefined identifier\n%s", src)
+	}
+}
+
+func TestGenerateInParams(t *testing.T) {
+	dir := filepath.Join("testdata", "in")
+	_, err := generate(dir, filepath.Join(dir, "ioc_gen.go"), "Graph")
+	if err == nil || !strings.Contains(err.Error(), "ioc.In") {
+		t.Errorf("expected error about parameter struct embedding ioc.In and got %v", err)
+	}
+}
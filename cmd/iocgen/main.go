@@ -0,0 +1,36 @@
+// iocgen generates reflection-free wiring for services registered with ioc.RegisterCtor.
+//
+// It reads every `ioc.RegisterCtor(b, NewService)` call in a package and emits a file with
+// a graph struct constructing all services in dependency order. Missing providers are
+// reported as undefined identifiers so generated code doesn't compile until they are provided.
+// Generated graph can still be exposed as an ioc.Dic for Get and GetServices users.
+//
+// Usage:
+//
+//	//go:generate go run github.com/ogiusek/ioc/v2/cmd/iocgen -type Graph -out ioc_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package with ioc.RegisterCtor calls")
+	out := flag.String("out", "ioc_gen.go", "output file name relative to the package directory")
+	typeName := flag.String("type", "Graph", "name of the generated graph type")
+	flag.Parse()
+
+	output := filepath.Join(*dir, *out)
+	src, err := generate(*dir, output, *typeName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "iocgen: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "iocgen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type Config struct{ Dsn string }
+type Logger struct{ Prefix string }
+type Db struct {
+	Logger *Logger
+	Config Config
+}
+type Repo interface{ Db() *Db }
+
+type repo struct{ db *Db }
+
+func (r repo) Db() *Db { return r.db }
+
+func NewConfig() Config   { return Config{Dsn: "memory"} }
+func NewLogger() *Logger  { return &Logger{Prefix: "app"} }
+func NewRepo(db *Db) Repo { return repo{db: db} }
+func NewDb(logger *Logger, config Config) (*Db, error) {
+	if config.Dsn == "" {
+		return nil, errors.New("empty dsn")
+	}
+	return &Db{Logger: logger, Config: config}, nil
+}
+
+var Pkg = ioc.NewPkg(func(b ioc.Builder) {
+	ioc.RegisterCtor(b, NewRepo)
+	ioc.RegisterCtor(b, NewDb)
+	ioc.RegisterCtor(b, NewLogger)
+	ioc.RegisterCtor(b, NewConfig)
+})
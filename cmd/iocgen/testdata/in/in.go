@@ -0,0 +1,17 @@
+package in
+
+import "github.com/ogiusek/ioc/v2"
+
+type Config struct{}
+type Service struct{}
+
+type Params struct {
+	ioc.In
+	Config Config `inject:""`
+}
+
+func NewService(params Params) Service { return Service{} }
+
+var Pkg = ioc.NewPkg(func(b ioc.Builder) {
+	ioc.RegisterCtor(b, NewService)
+})
@@ -0,0 +1,12 @@
+package missing
+
+import "github.com/ogiusek/ioc/v2"
+
+type Config struct{}
+type Service struct{}
+
+func NewService(config Config) Service { return Service{} }
+
+var Pkg = ioc.NewPkg(func(b ioc.Builder) {
+	ioc.RegisterCtor(b, NewService)
+})
@@ -0,0 +1,479 @@
+// Package iocvet defines an analyzer reporting misuse of the ioc container.
+//
+// It reports:
+//   - ioc.Get and ioc.GetServices of services which are never registered.
+//     ioc.TryGet and ioc.TryGetServices probe optional services so they aren't reported
+//   - ioc.Wrap of services which are never registered
+//   - `inject` tags on unexported fields
+//   - services registered by more than one package
+//
+// Services are known when they are registered by the analyzed package or by a package it imports.
+// Retrievals of services which aren't known are checked by packages calling ioc.NewContainer or ioc.TryNewContainer
+// against registrations of all packages they import, so services can be registered by packages importing the retrieving one.
+// Services registered by more than one of the imported packages are reported at the container too.
+// Retrievals aren't reported in packages which pass resolvers with ioc.WithResolver or import packages which do
+// because services provided by resolvers aren't known.
+//
+// It can be run with go vet:
+//
+//	go install github.com/ogiusek/ioc/v2/cmd/iocvet
+//	go vet -vettool=$(which iocvet) ./...
+package iocvet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const iocPath = "github.com/ogiusek/ioc/v2"
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "iocvet",
+	Doc:       "reports services retrieved but never registered, duplicate registrations and inject tags on unexported fields",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(registrations)},
+	Run:       run,
+}
+
+// registrations is a package fact listing services registered by the package
+type registrations struct {
+	// Services maps service type to position of its registration
+	Services map[string]string
+	// Products maps type of instances created by ioc.Factory to position of the factory registration.
+	// Products can be wrapped without being registered
+	Products map[string]string
+	// Overrides maps service type overridden or registered by default to position of the registration.
+	// They replace or yield to registrations so they are never duplicates
+	Overrides map[string]string
+	// Retrievals are retrievals of services not registered by the package nor packages it imports.
+	// They are checked by packages creating containers
+	Retrievals []retrieval
+	// Resolvers is set when the package passes resolvers with ioc.WithResolver.
+	// Services provided by resolvers aren't known so retrievals of packages importing it aren't reported
+	Resolvers bool
+}
+
+// retrieval is a retrieval of a service which has to be registered
+type retrieval struct {
+	Service string
+	// Family is a key of generic registration providing the service
+	Family string
+	// Wrap retrievals are satisfied by factory products
+	Wrap bool
+	// Call describes the retrieval e.g. "Get" or "GetServices field Repo"
+	Call string
+	Pos  string
+}
+
+// registered reports whether service retrieved by r is registered
+func (r retrieval) registered(services, products map[string]string) bool {
+	return services[r.Service] != "" || r.Family != "" && services[r.Family] != "" || r.Wrap && products[r.Service] != ""
+}
+
+func (*registrations) AFact() {}
+
+func (r *registrations) String() string {
+	var services []string
+	for service := range r.Services {
+		services = append(services, service)
+	}
+	for service := range r.Overrides {
+		if r.Services[service] == "" {
+			services = append(services, service)
+		}
+	}
+	slices.Sort(services)
+	if r.Resolvers {
+		return fmt.Sprintf("registrations(%s) with resolvers", strings.Join(services, ", "))
+	}
+	return fmt.Sprintf("registrations(%s)", strings.Join(services, ", "))
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	imported := map[string]string{}
+	importedProducts := map[string]string{}
+	importedOverrides := map[string]string{}
+	resolvers := false
+	for _, fact := range pass.AllPackageFacts() {
+		resolvers = resolvers || fact.Fact.(*registrations).Resolvers
+		maps.Copy(imported, fact.Fact.(*registrations).Services)
+		maps.Copy(importedProducts, fact.Fact.(*registrations).Products)
+		maps.Copy(importedOverrides, fact.Fact.(*registrations).Overrides)
+	}
+
+	local := &registrations{Services: map[string]string{}, Products: map[string]string{}, Overrides: map[string]string{}}
+	// the same service can be registered multiple times in a package for different containers
+	// so only registrations across packages are duplicates
+	register := func(pos token.Pos, service types.Type) {
+		if hasTypeParam(service) {
+			return
+		}
+		key := types.TypeString(service, nil)
+		if first, ok := imported[key]; ok {
+			pass.Reportf(pos, "service %s is already registered at %s", key, first)
+			return
+		}
+		if _, ok := local.Services[key]; !ok {
+			local.Services[key] = pass.Fset.Position(pos).String()
+		}
+	}
+
+	// adapter is ioc.Adapter interface. Types adapted by third party adapters aren't registered
+	var adapter *types.Interface
+	// services are collected before retrievals are checked because they can be retrieved before registration
+	var retrievals []token.Pos
+	retrieve := func(pos token.Pos, call string, t types.Type, wrap bool) {
+		service, ok := requested(t, adapter)
+		if !ok {
+			return
+		}
+		family, _ := genericFamily(service)
+		local.Retrievals = append(local.Retrievals, retrieval{
+			Service: types.TypeString(service, nil),
+			Family:  family,
+			Wrap:    wrap,
+			Call:    call,
+			Pos:     pass.Fset.Position(pos).String(),
+		})
+		retrievals = append(retrievals, pos)
+	}
+	// container is the first container created by the package
+	container := token.NoPos
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil), (*ast.Ident)(nil), (*ast.StructType)(nil)}, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.StructType:
+			checkUnexportedFields(pass, n)
+		case *ast.Ident:
+			fn, args, ok := iocFunc(pass.TypesInfo, n)
+			switch {
+			case !ok:
+			// MapServiceRegistryPkg is a package so it is referenced instead of called
+			case fn.Name() == "MapServiceRegistryPkg" && args.Len() == 2:
+				if registry, ok := iocType(fn.Pkg(), "ServiceRegistry", args.At(0), args.At(1)); ok {
+					register(n.Pos(), registry)
+				}
+			// resolvers can be passed by options built elsewhere so every reference counts
+			case fn.Name() == "WithResolver":
+				local.Resolvers = true
+			}
+		case *ast.CallExpr:
+			fn, args, ok := iocFunc(pass.TypesInfo, calledIdent(n.Fun))
+			if !ok {
+				return
+			}
+			if adapter == nil {
+				adapter = adapterInterface(fn.Pkg())
+			}
+			name := fn.Name()
+			switch name {
+			case "Register", "RegisterPrivate", "RegisterIf", "Provide", "RegisterKey", "ProvideKey":
+				register(n.Pos(), args.At(0))
+			case "Override", "RegisterDefault":
+				// overrides replace registrations and defaults yield to them so they are never duplicates
+				if key := types.TypeString(args.At(0), nil); !hasTypeParam(args.At(0)) && local.Services[key] == "" && imported[key] == "" && local.Overrides[key] == "" {
+					local.Overrides[key] = pass.Fset.Position(n.Pos()).String()
+				}
+			case "RegisterGeneric":
+				if family, ok := genericFamily(args.At(0)); ok {
+					if _, ok := local.Services[family]; !ok {
+						local.Services[family] = pass.Fset.Position(n.Pos()).String()
+					}
+				}
+			case "RegisterFactory":
+				if factory, ok := iocType(fn.Pkg(), "Factory", args.At(0), args.At(1)); ok {
+					register(n.Pos(), factory)
+				}
+				if product := args.At(1); !hasTypeParam(product) {
+					local.Products[types.TypeString(product, nil)] = pass.Fset.Position(n.Pos()).String()
+				}
+			case "RegisterMulti":
+				register(n.Pos(), args.At(0))
+				register(n.Pos(), args.At(1))
+			case "RegisterCtor":
+				if len(n.Args) != 2 {
+					return
+				}
+				sig, ok := pass.TypesInfo.TypeOf(n.Args[1]).Underlying().(*types.Signature)
+				if !ok || sig.Results().Len() == 0 {
+					return
+				}
+				service := sig.Results().At(0).Type()
+				if fields, ok := outFields(service); ok {
+					for _, field := range fields {
+						register(n.Pos(), field)
+					}
+					return
+				}
+				register(n.Pos(), service)
+			case "Supply":
+				for _, arg := range n.Args[1:] {
+					if t := pass.TypesInfo.TypeOf(arg); !types.IsInterface(t) {
+						register(arg.Pos(), t)
+					}
+				}
+			case "Get":
+				retrieve(n.Pos(), name, args.At(0), false)
+			case "Wrap":
+				retrieve(n.Pos(), name, args.At(0), true)
+			case "GetServices":
+				checkServices(n.Pos(), name+" field ", args.At(0), retrieve, map[types.Type]bool{})
+			case "NewContainer", "TryNewContainer":
+				if container == token.NoPos {
+					container = n.Pos()
+				}
+			}
+		}
+	})
+
+	services := merged(local.Services, local.Overrides, imported, importedOverrides)
+	products := merged(local.Products, importedProducts)
+	unregistered := local.Retrievals[:0]
+	resolvers = resolvers || local.Resolvers
+	for i, r := range local.Retrievals {
+		switch {
+		case resolvers, r.registered(services, products):
+		case container != token.NoPos:
+			pass.Reportf(retrievals[i], "%s of service %s which is never registered", r.Call, r.Service)
+		default:
+			unregistered = append(unregistered, r)
+		}
+	}
+	local.Retrievals = unregistered
+	if container != token.NoPos {
+		checkContainer(pass, container, services, products, resolvers)
+	}
+	if len(local.Services) != 0 || len(local.Products) != 0 || len(local.Overrides) != 0 || len(local.Retrievals) != 0 || local.Resolvers {
+		pass.ExportPackageFact(local)
+	}
+	return nil, nil
+}
+
+// checkContainer reports retrievals of imported packages which are never registered
+// and services registered by more than one imported package at the container
+func checkContainer(pass *analysis.Pass, container token.Pos, services, products map[string]string, resolvers bool) {
+	facts := pass.AllPackageFacts()
+	slices.SortFunc(facts, func(a, b analysis.PackageFact) int { return strings.Compare(a.Package.Path(), b.Package.Path()) })
+	// packages importing a package which registers the service report it themselves
+	// so only services registered by packages which don't import each other are left
+	registered := map[string]string{}
+	for _, fact := range facts {
+		r := fact.Fact.(*registrations)
+		for _, service := range slices.Sorted(maps.Keys(r.Services)) {
+			if first, ok := registered[service]; ok {
+				pass.Reportf(container, "service %s is registered at %s and at %s", service, first, r.Services[service])
+				continue
+			}
+			registered[service] = r.Services[service]
+		}
+		for _, retrieval := range r.Retrievals {
+			if !resolvers && !retrieval.registered(services, products) {
+				pass.Reportf(container, "%s of service %s at %s which is never registered", retrieval.Call, retrieval.Service, retrieval.Pos)
+			}
+		}
+	}
+}
+
+// merged returns union of registration maps. Earlier maps take precedence
+func merged(registrations ...map[string]string) map[string]string {
+	union := map[string]string{}
+	for _, r := range slices.Backward(registrations) {
+		maps.Copy(union, r)
+	}
+	return union
+}
+
+// calledIdent returns identifier of the called function
+func calledIdent(fun ast.Expr) *ast.Ident {
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		return calledIdent(f.X)
+	case *ast.IndexListExpr:
+		return calledIdent(f.X)
+	case *ast.SelectorExpr:
+		return f.Sel
+	case *ast.Ident:
+		return f
+	}
+	return nil
+}
+
+// iocFunc returns the ioc function referenced by ident and its type arguments
+func iocFunc(info *types.Info, ident *ast.Ident) (*types.Func, *types.TypeList, bool) {
+	if ident == nil {
+		return nil, nil, false
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != iocPath || fn.Signature().Recv() != nil {
+		return nil, nil, false
+	}
+	return fn, info.Instances[ident].TypeArgs, true
+}
+
+// iocType instantiates generic type of the ioc package
+func iocType(ioc *types.Package, name string, args ...types.Type) (types.Type, bool) {
+	obj, ok := ioc.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+	t, err := types.Instantiate(nil, obj.Type(), args, false)
+	return t, err == nil
+}
+
+// hasTypeParam reports whether t is or is instantiated with a type parameter
+func hasTypeParam(t types.Type) bool {
+	switch t := types.Unalias(t).(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return hasTypeParam(t.Elem())
+	case *types.Slice:
+		return hasTypeParam(t.Elem())
+	case *types.Map:
+		return hasTypeParam(t.Key()) || hasTypeParam(t.Elem())
+	case *types.Named:
+		for arg := range t.TypeArgs().Types() {
+			if hasTypeParam(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requested returns service which has to be registered to retrieve t
+func requested(t types.Type, adapter *types.Interface) (types.Type, bool) {
+	if hasTypeParam(t) {
+		return nil, false
+	}
+	// unnamed getters and slices are synthesized from the service
+	switch t := types.Unalias(t).(type) {
+	case *types.Signature:
+		if t.Params().Len() == 0 && t.Results().Len() == 1 {
+			return requested(t.Results().At(0).Type(), adapter)
+		}
+	case *types.Slice:
+		return requested(t.Elem(), adapter)
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != iocPath {
+		if adapter != nil && types.Implements(types.NewPointer(t), adapter) {
+			return nil, false
+		}
+		return t, true
+	}
+	switch named.Obj().Name() {
+	case "Optional":
+		return nil, false
+	case "Lazy", "Transient":
+		return requested(named.TypeArgs().At(0), adapter)
+	}
+	return t, true
+}
+
+// adapterInterface returns ioc.Adapter interface. It returns nil when ioc doesn't declare it
+func adapterInterface(ioc *types.Package) *types.Interface {
+	obj, ok := ioc.Scope().Lookup("Adapter").(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	adapter, _ := obj.Type().Underlying().(*types.Interface)
+	return adapter
+}
+
+// genericFamily returns key of the generic type t is instantiated from.
+// Pointer and value instantiations are different families like in ioc.RegisterGeneric
+func genericFamily(t types.Type) (string, bool) {
+	if pointer, ok := types.Unalias(t).(*types.Pointer); ok {
+		family, ok := genericFamily(pointer.Elem())
+		return "*" + family, ok
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.TypeArgs().Len() == 0 {
+		return "", false
+	}
+	return types.TypeString(named.Origin(), nil), true
+}
+
+// outFields returns types of unnamed services registered by result struct embedding ioc.Out
+func outFields(t types.Type) ([]types.Type, bool) {
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+	isOut := false
+	var fields []types.Type
+	for i := range structType.NumFields() {
+		field := structType.Field(i)
+		if named, ok := types.Unalias(field.Type()).(*types.Named); ok && field.Embedded() &&
+			named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == iocPath && named.Obj().Name() == "Out" {
+			isOut = true
+			continue
+		}
+		tag, ok := reflect.StructTag(structType.Tag(i)).Lookup("inject")
+		if !ok || slices.ContainsFunc(strings.Split(tag, ","), func(option string) bool { return strings.HasPrefix(option, "name=") }) {
+			continue
+		}
+		fields = append(fields, field.Type())
+	}
+	return fields, isOut
+}
+
+// checkServices retrieves fields with inject tag. prefix describes the call and path to the struct
+func checkServices(pos token.Pos, prefix string, t types.Type, retrieve func(token.Pos, string, types.Type, bool), visited map[types.Type]bool) {
+	if pointer, ok := t.Underlying().(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok || visited[t] {
+		return
+	}
+	visited[t] = true
+	for i := range structType.NumFields() {
+		tag, ok := reflect.StructTag(structType.Tag(i)).Lookup("inject")
+		if !ok {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		switch {
+		case slices.Contains(options, "optional"):
+		case slices.ContainsFunc(options, func(option string) bool { return strings.HasPrefix(option, "name=") }):
+		case slices.Contains(options, "embed"):
+			checkServices(pos, prefix+structType.Field(i).Name()+".", structType.Field(i).Type(), retrieve, visited)
+		default:
+			retrieve(pos, prefix+structType.Field(i).Name(), structType.Field(i).Type(), false)
+		}
+	}
+}
+
+func checkUnexportedFields(pass *analysis.Pass, s *ast.StructType) {
+	for _, field := range s.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		if _, ok := reflect.StructTag(tag).Lookup("inject"); !ok {
+			continue
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				pass.Reportf(name.Pos(), "inject tag on unexported field %s requires ioc.InjectUnexportedFields option", name.Name)
+			}
+		}
+	}
+}
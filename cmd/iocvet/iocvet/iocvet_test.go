@@ -0,0 +1,12 @@
+package iocvet_test
+
+import (
+	"testing"
+
+	"github.com/ogiusek/ioc/v2/cmd/iocvet/iocvet"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), iocvet.Analyzer, "db", "app", "mail", "smtp", "sendgrid", "server", "resolved")
+}
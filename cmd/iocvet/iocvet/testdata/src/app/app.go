@@ -0,0 +1,90 @@
+package app // want package:"registrations\\(\\*app.Repository\\[T any\\], app.Client, app.Config, app.Handler, app.Schema, app.Service, app.Worker, github.com/ogiusek/ioc/v2.Factory\\[app.Request, \\*app.Session\\], github.com/ogiusek/ioc/v2.ServiceRegistry\\[string, int\\]\\)"
+
+import (
+	"db"
+	"reflect"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type Config struct{}
+type Handler struct{}
+type Service struct{}
+type Missing struct{}
+type Client struct{}
+type Schema struct{}
+type Worker struct{}
+
+type Repository[T any] struct{}
+
+// Provider is a third party adapter
+type Provider[Service any] struct{ c ioc.Dic }
+
+func (p *Provider[Service]) Adapt(c ioc.Dic) error { p.c = c; return nil }
+
+type Request struct{}
+type Session struct{}
+
+type Outputs struct {
+	ioc.Out
+	Worker Worker  `inject:""`
+	Named  Missing `inject:"name=x"`
+}
+
+func NewOutputs() Outputs { return Outputs{} }
+
+type Services struct {
+	Conn     db.Conn               `inject:""`
+	Lazy     ioc.Lazy[db.Migrator] `inject:""`
+	Optional ioc.Optional[Missing] `inject:""`
+	Tagged   Missing               `inject:",optional"`
+	Named    Missing               `inject:"name=x"`
+	Embedded Embedded              `inject:",embed"`
+	Missing  Missing               `inject:""`
+	service  Service               `inject:""` // want "inject tag on unexported field service requires ioc.InjectUnexportedFields option"
+}
+
+type Embedded struct {
+	Config  Config  `inject:""`
+	Missing Missing `inject:""`
+}
+
+func Pkg(b ioc.Builder) {
+	ioc.Register(b, func(c ioc.Dic) Handler {
+		ioc.Get[Service](c) // registered later in the package
+		ioc.Get[db.Conn](c)
+		ioc.Get[ioc.Lazy[db.Migrator]](c)
+		ioc.Get[ioc.Optional[Missing]](c)
+		ioc.Get[ioc.ServiceRegistry[string, int]](c)
+		ioc.Get[Schema](c)
+		ioc.Get[Worker](c)
+		ioc.Get[ioc.Factory[Request, *Session]](c)
+		ioc.Get[*Session](c) // want "Get of service \\*app.Session which is never registered"
+		ioc.Get[*Repository[Service]](c)
+		ioc.Get[Repository[Service]](c) // want "Get of service app.Repository\\[app.Service\\] which is never registered"
+		ioc.Get[Missing](c)             // want "Get of service app.Missing which is never registered"
+		ioc.Get[Provider[Missing]](c)   // adapted by Provider
+		ioc.TryGet[Missing](c)          // optional services are probed with TryGet
+		ioc.TryGetServices[*Services](c)
+		ioc.GetServices[*Services](c) // want "GetServices field Embedded.Missing of service app.Missing which is never registered" "GetServices field Missing of service app.Missing which is never registered"
+		return Handler{}
+	})
+	ioc.Register(b, func(c ioc.Dic) Service { return Service{} })
+	ioc.Provide(b, Config{})
+	ioc.RegisterMulti(b, func(c ioc.Dic) (Client, Schema, error) { return Client{}, Schema{}, nil })
+	ioc.RegisterCtor(b, NewOutputs)
+	ioc.RegisterGeneric[*Repository[any]](b, func(c ioc.Dic, t reflect.Type) any { return nil })
+	ioc.Register(b, func(c ioc.Dic) db.Conn { return db.Conn{} }) // want "service db.Conn is already registered at .*db.go:11:2"
+	ioc.Register(b, func(c ioc.Dic) Service { return Service{} }) // registered again in the same package for other container
+	ioc.Override(b, func(c ioc.Dic) db.Migrator { return db.Migrator{} })
+	ioc.RegisterFactory(b, func(c ioc.Dic, r Request) *Session { return &Session{} })
+	ioc.Wrap(b, func(c ioc.Dic, s *Session) {})
+	ioc.Wrap(b, func(c ioc.Dic, s Missing) {}) // want "Wrap of service app.Missing which is never registered"
+}
+
+var _ = ioc.NewContainer(db.Pkg, Pkg, ioc.MapServiceRegistryPkg[string, int])
+
+func Generic[T any](b ioc.Builder) {
+	ioc.Register(b, func(c ioc.Dic) T { return ioc.Get[T](c) })
+	ioc.Register(b, func(c ioc.Dic) ioc.Lazy[T] { return ioc.Get[ioc.Lazy[T]](c) })
+}
@@ -0,0 +1,13 @@
+package db // want package:"registrations\\(db.Conn, db.Migrator\\)"
+
+import "github.com/ogiusek/ioc/v2"
+
+type Conn struct{}
+type Migrator struct{}
+
+func NewMigrator(conn Conn) Migrator { return Migrator{} }
+
+func Pkg(b ioc.Builder) {
+	ioc.Register(b, func(c ioc.Dic) Conn { return Conn{} })
+	ioc.RegisterCtor(b, NewMigrator)
+}
@@ -0,0 +1,42 @@
+// Package ioc is a stub of the ioc package with signatures used by the analyzer
+package ioc
+
+import "reflect"
+
+type Builder struct{}
+type Dic struct{}
+type Pkg func(b Builder)
+type Lazy[Service any] func() Service
+type Optional[Service any] struct{}
+type ServiceRegistry[Key, Service any] interface{}
+type Key[Service any] struct{}
+type Out struct{}
+type Factory[Args, Service any] func(args Args) Service
+type Adapter interface{ Adapt(c Dic) error }
+type Resolver interface {
+	Resolve(c Dic, t reflect.Type) (any, bool, error)
+}
+
+func Register[Service any](b Builder, creator func(c Dic) Service)                   { panic("stub") }
+func RegisterPrivate[Service any](b Builder, creator func(c Dic) Service)            { panic("stub") }
+func RegisterIf[Service any](b Builder, cond bool, creator func(c Dic) Service)      { panic("stub") }
+func Override[Service any](b Builder, creator func(c Dic) Service)                   { panic("stub") }
+func Provide[Service any](b Builder, value Service)                                  { panic("stub") }
+func RegisterKey[Service any](b Builder, creator func(c Dic) Service) Key[Service]   { panic("stub") }
+func ProvideKey[Service any](b Builder, value Service) Key[Service]                  { panic("stub") }
+func RegisterCtor(b Builder, ctor any)                                               { panic("stub") }
+func RegisterMulti[A, B any](b Builder, ctor func(c Dic) (A, B, error))              { panic("stub") }
+func RegisterGeneric[Family any](b Builder, factory func(c Dic, t reflect.Type) any) { panic("stub") }
+func RegisterFactory[Args, Service any](b Builder, ctor func(c Dic, args Args) Service) {
+	panic("stub")
+}
+func Supply(b Builder, values ...any)                              { panic("stub") }
+func Wrap[Service any](b Builder, wrap func(c Dic, s Service))     { panic("stub") }
+func MapServiceRegistryPkg[Key comparable, Service any](b Builder) { panic("stub") }
+func Get[T any](c Dic) T                                           { panic("stub") }
+func TryGet[T any](c Dic) (T, error)                               { panic("stub") }
+func GetServices[T any](c Dic) T                                   { panic("stub") }
+func TryGetServices[T any](c Dic) (T, error)                       { panic("stub") }
+func NewContainer(pkgs ...Pkg) Dic                                 { panic("stub") }
+func WithResolver(r Resolver) Pkg                                  { panic("stub") }
+func StructResolver() Resolver                                     { panic("stub") }
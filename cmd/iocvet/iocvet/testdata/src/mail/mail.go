@@ -0,0 +1,18 @@
+package mail // want package:"registrations\\(mail.Signup\\)"
+
+import "github.com/ogiusek/ioc/v2"
+
+// Mailer is declared by its consumer and registered by packages importing it
+type Mailer interface{ Send() }
+
+type Templates struct{}
+
+type Signup struct{}
+
+func Pkg(b ioc.Builder) {
+	ioc.Register(b, func(c ioc.Dic) Signup {
+		ioc.Get[Mailer](c)
+		ioc.Get[Templates](c) // reported by the package creating the container
+		return Signup{}
+	})
+}
@@ -0,0 +1,14 @@
+package resolved // want package:"registrations\\(\\) with resolvers"
+
+import "github.com/ogiusek/ioc/v2"
+
+type Repo struct{}
+
+type Handler struct {
+	Repo Repo `inject:""`
+}
+
+func Run() {
+	c := ioc.NewContainer(ioc.WithResolver(ioc.StructResolver()))
+	ioc.Get[Handler](c) // provided by resolver
+}
@@ -0,0 +1,14 @@
+package server
+
+import (
+	"mail"
+	"sendgrid"
+	"smtp"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func Run() {
+	ioc.NewContainer(mail.Pkg, smtp.Pkg, sendgrid.Pkg) // want "service mail.Mailer is registered at .*sendgrid.go:14:2 and at .*smtp.go:14:2" "Get of service mail.Templates at .*mail.go:15:3 which is never registered"
+	ioc.Get[mail.Signup](ioc.NewContainer(mail.Pkg, smtp.Pkg))
+}
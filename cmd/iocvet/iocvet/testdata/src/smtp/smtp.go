@@ -0,0 +1,15 @@
+package smtp // want package:"registrations\\(mail.Mailer\\)"
+
+import (
+	"mail"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type Mailer struct{}
+
+func (Mailer) Send() {}
+
+func Pkg(b ioc.Builder) {
+	ioc.Register(b, func(c ioc.Dic) mail.Mailer { return Mailer{} })
+}
@@ -0,0 +1,12 @@
+// iocvet reports misuse of the ioc container. It is meant to be run with go vet:
+//
+//	go install github.com/ogiusek/ioc/v2/cmd/iocvet
+//	go vet -vettool=$(which iocvet) ./...
+package main
+
+import (
+	"github.com/ogiusek/ioc/v2/cmd/iocvet/iocvet"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() { unitchecker.Main(iocvet.Analyzer) }
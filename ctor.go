@@ -0,0 +1,99 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// RegisterCtor registers result of ctor function with singleton lifetime.
+// Every ctor parameter is resolved from the container. Parameter structs embedding In have their fields injected.
+// ctor can return `Service` or `(Service, error)` and returned error fails the construction.
+// Tagged fields of result structs embedding Out are registered instead of the result struct.
+//
+// Example:
+//
+//	func NewService(logger Logger, repo Repo) (*Service, error)
+//
+//	ioc.RegisterCtor(b, NewService)
+//
+// Packages registering services with RegisterCtor can be turned into reflection-free code with cmd/iocgen
+func RegisterCtor(b Builder, ctor any) {
+	if err := validateCtor(ctor); err != nil {
+		throw(err)
+	}
+	fn := reflect.ValueOf(ctor)
+	fnType := fn.Type()
+	serviceType := fnType.Out(0)
+	if isOut(serviceType) {
+		b.registerOut(fnType.String(), serviceType, func(c Dic) (reflect.Value, error) {
+			out := fn.Call(c.resolveArgs(fnType))
+			if len(out) == 2 && !out[1].IsNil() {
+				return reflect.Value{}, out[1].Interface().(error)
+			}
+			return out[0], nil
+		})
+		return
+	}
+	b.register(serviceKey(serviceType), newService(func(c Dic) any {
+		out := fn.Call(c.resolveArgs(fnType))
+		if len(out) == 2 && !out[1].IsNil() {
+			panic(out[1].Interface())
+		}
+		return out[0].Interface()
+	}))
+}
+
+func validateCtor(ctor any) error {
+	fn := reflect.ValueOf(ctor)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return errors.Join(ErrInvalidServiceRegistration, fmt.Errorf("ctor has to be a function, got %T", ctor))
+	}
+	fnType := fn.Type()
+	if fnType.IsVariadic() {
+		return errors.Join(ErrInvalidServiceRegistration, fmt.Errorf("ctor '%s' cannot be variadic", fnType.String()))
+	}
+	switch {
+	case fnType.NumOut() == 1:
+	case fnType.NumOut() == 2 && fnType.Out(1) == errorType:
+	default:
+		return errors.Join(ErrInvalidServiceRegistration, fmt.Errorf("ctor '%s' has to return service or service and error", fnType.String()))
+	}
+	return nil
+}
+
+// resolveArgs injects every parameter of the function.
+// Panics with *Error when parameter cannot be injected
+func (c Dic) resolveArgs(fnType reflect.Type) []reflect.Value {
+	args, err := c.tryResolveArgs(fnType)
+	if err != nil {
+		throw(err)
+	}
+	return args
+}
+
+// tryResolveArgs injects every parameter of the function. Parameter structs embedding In have their fields injected
+func (c Dic) tryResolveArgs(fnType reflect.Type) ([]reflect.Value, error) {
+	args := make([]reflect.Value, fnType.NumIn())
+	for i := range args {
+		paramType := fnType.In(i)
+		arg := reflect.New(paramType)
+		var err error
+		switch {
+		case !isParams(paramType):
+			err = c.Inject(arg.Interface())
+		case paramType.Kind() == reflect.Pointer:
+			arg.Elem().Set(reflect.New(paramType.Elem()))
+			err = c.InjectServices(arg.Elem().Interface())
+		default:
+			err = c.InjectServices(arg.Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d of '%s': %w", i, fnType.String(), err)
+		}
+		args[i] = arg.Elem()
+	}
+	return args, nil
+}
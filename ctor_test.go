@@ -0,0 +1,63 @@
+package ioc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type ctorLogger struct{ Prefix string }
+type ctorService struct {
+	Logger *ctorLogger
+	Val    int
+}
+
+func newCtorLogger() *ctorLogger { return &ctorLogger{Prefix: "log"} }
+func newCtorService(logger *ctorLogger, val int) (*ctorService, error) {
+	if val < 0 {
+		return nil, errCtorNegative
+	}
+	return &ctorService{Logger: logger, Val: val}, nil
+}
+
+var errCtorNegative = errors.New("negative value")
+
+func TestRegisterCtor(t *testing.T) {
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterCtor(b, newCtorService)
+		ioc.RegisterCtor(b, newCtorLogger)
+		ioc.Provide(b, 7)
+	})
+
+	service := ioc.Get[*ctorService](c)
+	if service.Val != 7 || service.Logger != ioc.Get[*ctorLogger](c) {
+		t.Errorf("unexpected service %v", service)
+	}
+}
+
+func TestRegisterCtorError(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ioc.ErrConstructionFailed) || !errors.Is(err, errCtorNegative) {
+			t.Errorf("expected ctor error and got %v", err)
+		}
+	}()
+	ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterCtor(b, newCtorService)
+		ioc.RegisterCtor(b, newCtorLogger)
+		ioc.Provide(b, -1)
+	})
+}
+
+func TestRegisterInvalidCtor(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ioc.ErrInvalidServiceRegistration) {
+			t.Errorf("expected ErrInvalidServiceRegistration and got %v", err)
+		}
+	}()
+	ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterCtor(b, func() (int, int) { return 0, 0 })
+	})
+}
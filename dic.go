@@ -3,109 +3,530 @@ package ioc
 import (
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+
+	"github.com/ogiusek/ioc/v2/internal/hooks"
 )
 
 type dic struct {
-	serviceRegisterMutex *sync.Mutex
-	services             map[serviceID]service
+	// index is a position of the service in services and keys
+	index map[serviceID]int
+	// services and their keys in registration order. Keys index services directly
+	services []service
+	keys     []serviceID
+	// wraps are composed wraps by service key including wraps of unregistered services
+	wraps map[serviceID]func(Dic, any)
+	// plans are injection plans by struct type
+	plans sync.Map
+	// adapted are adapters and synthesized getters by adaptedID
+	adapted sync.Map
 
 	creationMapMutex sync.Mutex
-	creationMap      map[serviceID]struct{}
+	// creationDone is broadcast when service stops being created. It uses creationMapMutex
+	creationDone sync.Cond
+	// created are services in creation order
+	created []serviceID
+
+	resolvers     []Resolver
+	resolvedMutex sync.Mutex
+	// resolved are services resolved by resolvers. Types which no resolver resolves aren't created
+	resolved map[serviceID]service
+
+	injectUnexported bool
+	retryFailed      bool
+
+	sealed          atomic.Bool
+	sealPolicy      SealPolicy
+	violationsMutex sync.Mutex
+	violations      []*SealViolation
 }
 
 type Dic struct {
 	c *dic
+	// module of the service which is being created
+	module string
+	// creating is set while creator of the service which received container is called.
+	// Retrievals of creators are wiring so sealed container doesn't report them.
+	// Container stored by a service is reported again after its creator returns
+	creating *atomic.Bool
+	// resolution is the retrieval which creates services requested through the container
+	resolution *resolution
+}
+
+// resolution is a top-level retrieval and nested retrievals of creators it calls.
+// Services are owned by resolution creating them so other resolutions wait for them
+// and resolution requesting service it owns has circular dependency
+type resolution struct {
+	// waiting is an owner of the service which resolution waits for. It is guarded by creationMapMutex
+	waiting **resolution
+	// done is set when top-level retrieval returns so containers stored by services start new resolutions
+	done atomic.Bool
+	// transients are services created by Transient in the resolution
+	transients []serviceID
+}
+
+// wiring marks containers which are never reported by sealed container, e.g. containers passed to wraps
+var wiring = func() *atomic.Bool {
+	var wiring atomic.Bool
+	wiring.Store(true)
+	return &wiring
+}()
+
+// in returns container resolving services on behalf of the module
+func (c Dic) in(module string) Dic {
+	c.module = module
+	return c
+}
+
+// during returns container which isn't reported by sealed container while *creating is set
+func (c Dic) during(creating *atomic.Bool) Dic {
+	c.creating = creating
+	return c
+}
+
+// visible returns ErrPrivateService when service cannot be resolved from the container module
+func (c Dic) visible(key serviceID, service service) error {
+	if !service.private || service.module == c.module {
+		return nil
+	}
+	requester := fmt.Sprintf("module '%s'", c.module)
+	if c.module == "" {
+		requester = "outside of modules"
+	}
+	return errors.Join(
+		ErrPrivateService,
+		fmt.Errorf("service of type '%s' is private to module '%s' and cannot be resolved from %s", keyType(key).String(), service.module, requester),
+	)
 }
 
 func serviceKey(serviceType reflect.Type) serviceID {
-	return reflect.Zero(reflect.PointerTo(serviceType)).Interface()
+	return serviceType
+}
+
+// namedServiceID identifies service registered with a name
+type namedServiceID struct {
+	service serviceID
+	name    string
+}
+
+func namedKey(key serviceID, name string) serviceID {
+	if name == "" {
+		return key
+	}
+	return namedServiceID{service: key, name: name}
+}
+
+func nameSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" named '%s'", name)
+}
+
+func keyType(key serviceID) reflect.Type {
+	if named, ok := key.(namedServiceID); ok {
+		key = named.service
+	}
+	return key.(reflect.Type)
+}
+
+// lookup returns registered service
+func (c Dic) lookup(key serviceID) (service, bool) {
+	i, ok := c.c.index[key]
+	if !ok {
+		return service{}, false
+	}
+	return c.c.services[i], true
 }
 
-func (c Dic) tryLock(id serviceID) bool {
+// lockService marks service as being created by the resolution and waits while other resolution creates it.
+// Returns false when service is requested during its own creation, also when resolutions would wait for each other.
+// Services are marked in place so creation doesn't hash keys
+func (c Dic) lockService(service service, r *resolution) bool {
 	c.c.creationMapMutex.Lock()
 	defer c.c.creationMapMutex.Unlock()
-
-	_, ok := c.c.creationMap[id]
-	c.c.creationMap[id] = struct{}{}
-	return !ok
+	for *service.owner != nil {
+		for owner := *service.owner; owner != nil; owner = *owner.waiting {
+			if owner == r {
+				return false
+			}
+			if owner.waiting == nil {
+				break
+			}
+		}
+		r.waiting = service.owner
+		c.c.creationDone.Wait()
+		r.waiting = nil
+	}
+	*service.owner = r
+	service.creating.Store(true)
+	return true
 }
-func (c Dic) unlock(id serviceID) {
+
+// unlockService unmarks service, records created service key and wakes resolutions waiting for it
+func (c Dic) unlockService(service service, created serviceID) {
 	c.c.creationMapMutex.Lock()
 	defer c.c.creationMapMutex.Unlock()
-	delete(c.c.creationMap, id)
+	*service.owner = nil
+	service.creating.Store(false)
+	if created != nil {
+		c.c.created = append(c.c.created, created)
+	}
+	c.c.creationDone.Broadcast()
+}
+
+// resolving returns container creating services in its resolution.
+// done is called when retrieval returns and it is nil when retrieval is nested
+func (c Dic) resolving() (Dic, func()) {
+	if c.resolution != nil && !c.resolution.done.Load() {
+		return c, nil
+	}
+	c.resolution = &resolution{}
+	return c, func() { c.resolution.done.Store(true) }
+}
+
+// nested reports whether container retrieves services for creators of unfinished resolution
+func (c Dic) nested() bool {
+	return c.resolution != nil && !c.resolution.done.Load()
+}
+
+// detached returns container of the module which starts its own resolutions and is reported by sealed container
+func (c Dic) detached() Dic {
+	return Dic{c: c.c, module: c.module}
+}
+
+// create returns service instance and creates it when it isn't created yet.
+// Returns ErrCircularDependency when service is requested during its own creation
+// and ErrConstructionFailed when creator panics
+func (c Dic) create(key serviceID, service service) (any, error) {
+	if service.created() {
+		return *service.instance, nil
+	}
+	c, done := c.resolving()
+	if done != nil {
+		defer done()
+	}
+	if ok := c.lockService(service, c.resolution); !ok {
+		return nil, errors.Join(
+			ErrCircularDependency,
+			fmt.Errorf("service of type '%s' is requested before being registered", keyType(key).String()),
+		)
+	}
+	switch service.state.Load() {
+	case serviceCreated:
+		c.unlockService(service, nil)
+		return *service.instance, nil
+	case serviceFailed:
+		c.unlockService(service, nil)
+		return nil, *service.err
+	}
+	instance, err := c.construct(key, service, service.creating)
+	if err != nil {
+		if !c.c.retryFailed {
+			*service.err = err
+			service.state.Store(serviceFailed)
+		}
+		c.unlockService(service, nil)
+		return nil, err
+	}
+	*service.instance = instance
+	service.state.Store(serviceCreated)
+	c.unlockService(service, key)
+	service.wraps(c.during(wiring), instance)
+	return instance, nil
+}
+
+// construct calls creator and converts its panic into an error.
+// Retrievals of the creator aren't reported by sealed container while creating is set
+func (c Dic) construct(key serviceID, service service, creating *atomic.Bool) (instance any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Join(
+				ErrConstructionFailed,
+				fmt.Errorf("service of type '%s' failed to construct: %w", keyType(key).String(), &PanicError{Service: keyType(key), Value: r, Stack: debug.Stack()}),
+			)
+		}
+	}()
+	return service.creator(c.in(service.module).during(creating)), nil
+}
+
+// Services returns types of services which can be retrieved from outside of modules in registration order.
+// Named and private services are skipped
+func (c Dic) Services() []reflect.Type {
+	var res []reflect.Type
+	for i, key := range c.c.keys {
+		if _, ok := key.(namedServiceID); ok {
+			continue
+		}
+		service := c.c.services[i]
+		if service.private {
+			continue
+		}
+		res = append(res, keyType(key))
+	}
+	return res
+}
+
+// Registration describes registered service
+type Registration struct {
+	Type reflect.Type
+	// Name is empty when service is registered without a name
+	Name string
+	// Module which registered the service. It is empty when service is registered by a package
+	Module  string
+	Private bool
+}
+
+func (r Registration) String() string {
+	if r.Module == "" {
+		return r.Type.String() + nameSuffix(r.Name)
+	}
+	return fmt.Sprintf("%s%s in module '%s'", r.Type.String(), nameSuffix(r.Name), r.Module)
+}
+
+// Registrations returns every registered service in registration order including named and private services
+func (c Dic) Registrations() []Registration {
+	res := make([]Registration, len(c.c.keys))
+	for i, key := range c.c.keys {
+		service := c.c.services[i]
+		res[i] = Registration{Type: keyType(key), Module: service.module, Private: service.private}
+		if named, ok := key.(namedServiceID); ok {
+			res[i].Name = named.name
+		}
+	}
+	return res
+}
+
+func init() {
+	hooks.ResolveRegistration = func(c, registration any) (any, error) {
+		return c.(Dic).resolveRegistration(registration.(Registration))
+	}
+}
+
+// resolveRegistration returns service of the registration on behalf of the module which registered it so private services are resolved too.
+// It is used by ioctest to check wiring and isn't exported so module boundaries hold for other callers
+func (c Dic) resolveRegistration(r Registration) (any, error) {
+	if err := c.checkSealed(r.Type); err != nil {
+		return nil, err
+	}
+	key := namedKey(serviceKey(r.Type), r.Name)
+	service, ok := c.lookup(key)
+	if !ok {
+		return nil, missing(key, r.Name)
+	}
+	return c.in(service.module).create(key, service)
+}
+
+// Close closes created services implementing io.Closer in reverse creation order.
+// Returns errors of all failed Close calls
+func (c Dic) Close() error {
+	c.c.creationMapMutex.Lock()
+	created := c.c.created
+	c.c.created = nil
+	c.c.creationMapMutex.Unlock()
+
+	var errs []error
+	for _, key := range slices.Backward(created) {
+		service, _ := c.lookup(key)
+		closer, ok := (*service.instance).(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing service of type '%s': %w", keyType(key).String(), err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Inject replaces servicePointer value with a service from container.
 // Can return ErrServiceIsntRegistered or ErrIsntPointer
 func (c Dic) Inject(servicePointer any) error {
+	if t := reflect.TypeOf(servicePointer); t != nil && t.Kind() == reflect.Pointer {
+		if err := c.checkSealed(servicePointer); err != nil {
+			return err
+		}
+	}
+	return c.injectNamed(servicePointer, "")
+}
+
+func (c Dic) injectNamed(servicePointer any, name string) error {
+	ok, err := c.find(servicePointer, name)
+	if !ok && err == nil {
+		return missing(namedKey(serviceKey(reflect.TypeOf(servicePointer).Elem()), name), name)
+	}
+	return err
+}
+
+// find sets service pointed by servicePointer. Returns false without error when service isn't registered
+// and no adapter, synthesized service nor resolver provides it
+func (c Dic) find(servicePointer any, name string) (bool, error) {
 	if servicePointer == nil {
-		return ErrIsntPointer
+		return false, ErrIsntPointer
 	}
 	serviceValue := reflect.ValueOf(servicePointer)
 	if serviceValue.Kind() != reflect.Pointer {
-		return ErrIsntPointer
+		return false, ErrIsntPointer
 	}
 	serviceElement := serviceValue.Elem()
 
-	key := serviceKey(serviceElement.Type())
+	key := namedKey(serviceKey(serviceElement.Type()), name)
 
-	service, ok := c.c.services[key]
+	service, ok := c.lookup(key)
 	if !ok {
-		return errors.Join(
-			ErrServiceIsntRegistered,
-			fmt.Errorf("service of type '%s' is not registered", serviceElement.Type().String()),
-		)
+		instance, ok, err := c.unregistered(key, name, servicePointer)
+		if ok && err == nil {
+			setService(serviceElement, instance)
+		}
+		return ok, err
 	}
 
-	instance := *service.instance
-	if instance == nil {
-		if ok := c.tryLock(key); !ok {
-			panic(errors.Join(
-				ErrCircularDependency,
-				fmt.Errorf("service of type '%s' is requested before being registered", serviceElement.Type().String()),
-			))
-		}
-		instance = service.creator(c)
-		*service.instance = instance
-		c.c.services[key] = service
-		c.unlock(key)
-		service.wraps(c, instance)
-	}
-
-	var newServiceValue reflect.Value
-	switch serviceElement.Type().Kind() {
-	case reflect.Interface:
-		if instance == nil {
-			newServiceValue = reflect.ValueOf(&instance).Elem()
-		} else {
-			newServiceValue = reflect.ValueOf(instance)
+	if err := c.visible(key, service); err != nil {
+		return true, err
+	}
+	instance, err := c.create(key, service)
+	if err != nil {
+		return true, err
+	}
+
+	setService(serviceElement, instance)
+	return true, nil
+}
+
+// unregistered returns service which isn't registered. ptr points to zero value of the service and is adapted by adapters.
+// Returns false without error when no adapter, synthesized service nor resolver provides the service
+func (c Dic) unregistered(key serviceID, name string, ptr any) (any, bool, error) {
+	if name != "" {
+		return nil, false, nil
+	}
+	if instance, ok := c.loadAdapted(key); ok {
+		return instance, true, nil
+	}
+	if !c.nested() {
+		// adapted services are cached so they keep container without resolution of the first retrieval
+		c = c.detached()
+	}
+	if adapter, ok := ptr.(Adapter); ok {
+		if err := adapter.Adapt(c); err != nil {
+			return nil, true, err
 		}
-	default:
-		newServiceValue = reflect.ValueOf(instance)
+		instance := reflect.ValueOf(ptr).Elem().Interface()
+		c.storeAdapted(key, instance)
+		return instance, true, nil
+	}
+	return c.synthesize(key)
+}
+
+// loadAdapted returns cached adapter or synthesized getter.
+// Retrievals of creators adapt services with their resolution so circular dependencies are detected
+// and they aren't cached
+func (c Dic) loadAdapted(key serviceID) (any, bool) {
+	if c.nested() {
+		return nil, false
+	}
+	return c.c.adapted.Load(c.adaptedID(key))
+}
+
+// storeAdapted caches adapter or synthesized getter adapted outside of resolution
+func (c Dic) storeAdapted(key serviceID, instance any) {
+	if c.nested() {
+		return
+	}
+	c.c.adapted.Store(c.adaptedID(key), instance)
+}
+
+// moduleAdaptedID identifies service adapted for a module. Adapters check visibility so they are cached per module
+type moduleAdaptedID struct {
+	service serviceID
+	module  string
+}
+
+// adaptedID identifies adapted service. Services adapted outside of modules are identified by their key
+// which is cheaper to hash
+func (c Dic) adaptedID(key serviceID) any {
+	if c.module == "" {
+		return key
 	}
+	return moduleAdaptedID{service: key, module: c.module}
+}
 
+// missing is an error of service which isn't provided
+func missing(key serviceID, name string) error {
+	return errors.Join(
+		ErrServiceIsntRegistered,
+		fmt.Errorf("service of type '%s'%s is not registered", keyType(key).String(), nameSuffix(name)),
+	)
+}
+
+// setService sets service element to the instance. nil instance sets zero value
+func setService(serviceElement reflect.Value, instance any) {
+	newServiceValue := reflect.Zero(serviceElement.Type())
+	if instance != nil {
+		newServiceValue = reflect.ValueOf(instance)
+	}
 	serviceElement.Set(newServiceValue)
-	return nil
+}
+
+// injectTag is parsed `inject:"[option][,option...]"` struct tag
+type injectTag struct {
+	// name of the named service
+	name string
+	// fields of the struct are injected instead of the struct itself
+	embed bool
+	// field is left with zero value when service isn't registered
+	optional bool
+}
+
+func parseInjectTag(tag string) (injectTag, error) {
+	var res injectTag
+	for option := range strings.SplitSeq(tag, ",") {
+		switch {
+		case option == "", option == "1": // "1" is kept for backward compatibility
+		case option == "embed":
+			res.embed = true
+		case option == "optional":
+			res.optional = true
+		case strings.HasPrefix(option, "name="):
+			res.name = strings.TrimPrefix(option, "name=")
+		default:
+			return res, errors.Join(ErrInvalidInjectTag, fmt.Errorf("unknown option '%s'", option))
+		}
+	}
+	if res.embed && (res.optional || res.name != "") {
+		return res, errors.Join(ErrInvalidInjectTag, fmt.Errorf("embed option cannot be combined with other options"))
+	}
+	return res, nil
 }
 
 // InjectServices injects dependencies into the provided struct.
 //
 // The parameter `services` must be a pointer to a struct. All fields of this struct
-// that have the tag `inject:"1"` will be automatically injected with corresponding
-// instances from the DI container.
+// that have the `inject` tag will be automatically injected with corresponding
+// instances from the DI container. Tag is a comma separated list of options:
+//   - `inject:""` injects service of the field type
+//   - `inject:"name=x"` injects service of the field type registered with RegisterNamed under name x
+//   - `inject:",optional"` leaves field with zero value when service isn't registered
+//   - `inject:",embed"` injects fields of the struct (or pointer to a struct) field instead of the field itself
+//
+// Unexported fields with `inject` tag are injected only with InjectUnexportedFields option.
 //
 // Example:
 //
 //	type MyServices struct {
-//	    Logger Logger `inject:"1"`
-//	    Repo   Repo   `inject:"1"`
+//	    Logger Logger `inject:""`
+//	    Repo   Repo   `inject:"name=users"`
+//	    Sentry Sentry `inject:",optional"`
 //	}
 //	var svc MyServices
 //	dic.InjectServices(&svc)
 //
-// can return ErrIsntPointerToStruct error or any error returned by c.Inject() method
+// can return ErrIsntPointerToStruct error or an error listing every field which couldn't be injected
 func (c Dic) InjectServices(services any) error {
 	servicePointer := reflect.ValueOf(services)
 	if servicePointer.Kind() != reflect.Pointer {
@@ -123,37 +544,12 @@ func (c Dic) InjectServices(services any) error {
 		)
 	}
 
-	serviceType := serviceElem.Type()
-	fields := serviceType.NumField()
-
-	injected := false
-
-	for i := range fields {
-		field := serviceType.Field(i)
-		if _, ok := field.Tag.Lookup("inject"); !ok {
-			continue
-		}
-
-		fieldPointer := serviceElem.Field(i).Addr().Interface()
-		if err := c.Inject(fieldPointer); err == nil {
-			injected = true
-			continue
-		}
-		if err := c.InjectServices(fieldPointer); err != nil {
-			return errors.Join(
-				ErrServiceIsntRegistered,
-				fmt.Errorf("service %v isn't registered", field.Type.String()),
-			)
-		}
-		injected = true
+	if err := c.checkSealed(servicePointer.Interface()); err != nil {
+		return err
 	}
-
-	if !injected {
-		return errors.Join(
-			ErrServiceIsntRegistered,
-			fmt.Errorf("service %v isn't registered", serviceType.String()),
-		)
+	plan := c.plan(serviceElem.Type())
+	if plan.direct {
+		return errors.Join(c.injectDirect(plan, serviceElem.Addr().UnsafePointer())...)
 	}
-
-	return nil
+	return errors.Join(c.inject(plan, serviceElem.Addr().UnsafePointer(), nil)...)
 }
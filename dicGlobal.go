@@ -2,56 +2,116 @@ package ioc
 
 import (
 	"errors"
-	"fmt"
 	"reflect"
+	"unsafe"
 )
 
 func typeKey[T any]() serviceID {
-	return (*T)(nil)
+	return reflect.TypeFor[T]()
 }
 
 // Returns service instance of type T.
 // Returns error when T is not registered
 func TryGet[T any](c Dic) (T, error) {
 	key := typeKey[T]()
+	i, registered := c.c.index[key]
+	// created services are returned without copying service and building error paths
+	if registered && !c.c.sealed.Load() {
+		if service := &c.c.services[i]; service.created() && !service.private {
+			instance, _ := (*service.instance).(T) // nil interfaces are valid services
+			return instance, nil
+		}
+	}
+	if err := c.checkSealed(key); err != nil {
+		var t T
+		return t, err
+	}
+	if !registered {
+		service, ok, err := findUnregistered[T](c, key, "")
+		if !ok && err == nil {
+			err = missing(key, "")
+		}
+		return service, err
+	}
+	return tryGet[T](c, key, "")
+}
 
-	service, ok := c.c.services[key]
-	if !ok {
+// Returns service instance of type T registered with RegisterNamed.
+// Returns error when T is not registered under the name
+func TryGetNamed[T any](c Dic, name string) (T, error) {
+	if err := c.checkSealed(typeKey[T]()); err != nil {
 		var t T
-		return t, errors.Join(
-			ErrServiceIsntRegistered,
-			fmt.Errorf("service of type '%s' is not registered", reflect.TypeFor[T]().String()),
-		)
+		return t, err
 	}
+	return tryGet[T](c, namedKey(typeKey[T](), name), name)
+}
 
-	if instance := *service.instance; instance != nil {
-		return instance.(T), nil
-	}
-	if ok := c.tryLock(key); !ok {
-		panic(errors.Join(
-			ErrCircularDependency,
-			fmt.Errorf("service of type '%s' is requested before being registered", reflect.TypeFor[T]().String()),
-		))
-	}
-	if instance := *service.instance; instance != nil {
-		c.unlock(key)
-		return instance.(T), nil
-	}
-	instance := service.creator(c)
-	*service.instance = instance
-	c.c.services[key] = service
-	c.unlock(key)
-	service.wraps(c, instance)
+func tryGet[T any](c Dic, key serviceID, name string) (T, error) {
+	service, ok, err := find[T](c, key, name)
+	if !ok && err == nil {
+		err = missing(key, name)
+	}
+	return service, err
+}
+
+// find returns service of the key. Returns false without error when service isn't registered
+// and no adapter, synthesized service nor resolver provides it
+func find[T any](c Dic, key serviceID, name string) (T, bool, error) {
+	service, ok := c.lookup(key)
+	if !ok {
+		return findUnregistered[T](c, key, name)
+	}
 
-	return instance.(T), nil
+	if service.private {
+		if err := c.visible(key, service); err != nil {
+			var t T
+			return t, true, err
+		}
+	}
+	if service.created() {
+		instance, _ := (*service.instance).(T)
+		return instance, true, nil
+	}
+	instance, err := c.create(key, service)
+	if err != nil {
+		var t T
+		return t, true, err
+	}
+	typed, _ := instance.(T) // nil interfaces are valid services
+	return typed, true, nil
+}
+
+// findUnregistered returns service which isn't registered.
+// Cached adapters are returned before adapted value is allocated
+func findUnregistered[T any](c Dic, key serviceID, name string) (T, bool, error) {
+	if name == "" {
+		if instance, ok := c.loadAdapted(key); ok {
+			typed, _ := instance.(T)
+			return typed, true, nil
+		}
+	}
+	var t T
+	instance, ok, err := c.unregistered(key, name, &t)
+	typed, _ := instance.(T)
+	return typed, ok, err
 }
 
 // Returns service instance of type T.
-// Panics when T is not registered
+// Panics with *Error when T is not registered
 func Get[T any](c Dic) T {
 	s, err := TryGet[T](c)
 	if err != nil {
-		panic(err.Error())
+		throw(err)
+	}
+	return s
+}
+
+// Returns service instance of type T registered with RegisterNamed.
+// Panics with *Error when T is not registered under the name
+func GetNamed[T any](c Dic, name string) T {
+	s, err := TryGetNamed[T](c, name)
+	if err != nil {
+		throw(err)
 	}
 	return s
 }
@@ -71,26 +131,42 @@ func Get[T any](c Dic) T {
 //
 // Note: If T is not a struct type, or if injection fails, this function may panic.
 func TryGetServices[T any](c Dic) (T, error) {
-	var res T
 	t := reflect.TypeFor[T]()
-	if t.Kind() == reflect.Pointer {
-		reflect.ValueOf(&res).Elem().Set(reflect.New(t.Elem()))
-		err := c.InjectServices(res)
+	if t.Kind() != reflect.Struct {
+		ptr := new(T)
+		if t.Kind() == reflect.Pointer {
+			reflect.ValueOf(ptr).Elem().Set(reflect.New(t.Elem()))
+			err := c.InjectServices(*ptr)
+			return *ptr, err
+		}
+		err := c.InjectServices(ptr)
+		return *ptr, err
+	}
+	if err := c.checkSealed(typeKey[T]()); err != nil {
+		var res T
 		return res, err
 	}
-	err := c.InjectServices(&res)
+	plan := c.plan(t)
+	if !plan.direct {
+		ptr := new(T)
+		err := errors.Join(c.inject(plan, unsafe.Pointer(ptr), nil)...)
+		return *ptr, err
+	}
+	// direct plan doesn't let res escape so it isn't allocated
+	var res T
+	err := errors.Join(c.injectDirect(plan, unsafe.Pointer(&res))...)
 	return res, err
 }
 
 // GetServices creates a new instance of type T where T is struct or pointer to a struct and
 // injects services into every public property with inject struct tag.
-// This function panics when injection fails.
+// This function panics with *Error when injection fails.
 // This is an intentional choice, its go idiomatic because its on startup.
 // It ensures application consistency and there is no proper way to handle invalid application wiring.
 func GetServices[T any](c Dic) T {
 	res, err := TryGetServices[T](c)
 	if err != nil {
-		panic(err.Error())
+		throw(err)
 	}
 	return res
 }
@@ -1,6 +1,9 @@
 package ioc_test
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
 	"sync"
 	"testing"
 
@@ -18,6 +21,58 @@ func BenchmarkNewContainerWith3Services(b *testing.B) {
 	}
 }
 
+func BenchmarkNewContainer(b *testing.B) {
+	for _, n := range []int{10, 1_000, 10_000} {
+		// every service has distinct type like in real containers
+		values := make([]any, n)
+		for i := range values {
+			values[i] = reflect.Zero(reflect.ArrayOf(i, reflect.TypeFor[struct{}]())).Interface()
+		}
+		pkg := ioc.NewPkg(func(b ioc.Builder) {
+			ioc.Supply(b, values...)
+		})
+		b.Run(fmt.Sprintf("%d services", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				ioc.NewContainer(pkg)
+			}
+		})
+	}
+}
+
+func BenchmarkNewContainerNamed(b *testing.B) {
+	for _, n := range []int{10, 1_000, 10_000} {
+		pkg := ioc.NewPkg(func(b ioc.Builder) {
+			for i := range n {
+				ioc.RegisterNamed(b, strconv.Itoa(i), func(c ioc.Dic) int { return i })
+			}
+		})
+		b.Run(fmt.Sprintf("%d services", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				ioc.NewContainer(pkg)
+			}
+		})
+	}
+}
+
+func BenchmarkNewContainerRepeated(b *testing.B) {
+	pkgs := []ioc.Pkg{
+		ioc.NewPkg(func(b ioc.Builder) {
+			ioc.Register(b, func(c ioc.Dic) int16 { return 0 })
+			ioc.Register(b, func(c ioc.Dic) int32 { return int32(ioc.Get[int16](c)) + 1 })
+		}),
+		ioc.NewPkg(func(b ioc.Builder) {
+			ioc.Register(b, func(c ioc.Dic) int64 { return 0 })
+			ioc.Wrap(b, func(c ioc.Dic, s int64) {})
+		}),
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		ioc.NewContainer(pkgs...)
+	}
+}
+
 func BenchmarkGet(b *testing.B) {
 	initial := 1
 	c := ioc.NewContainer(
@@ -32,6 +87,21 @@ func BenchmarkGet(b *testing.B) {
 	}
 }
 
+func BenchmarkKeyGet(b *testing.B) {
+	initial := 1
+	var key ioc.Key[int]
+	c := ioc.NewContainer(
+		func(b ioc.Builder) {
+			key = ioc.RegisterKey(b, func(d ioc.Dic) int { return initial })
+		},
+	)
+
+	b.ResetTimer()
+	for b.Loop() {
+		key.Get(c)
+	}
+}
+
 func BenchmarkLazyGet(b *testing.B) {
 	initial := 1
 	c := ioc.NewContainer(
@@ -71,6 +141,7 @@ func BenchmarkGetServices(b *testing.B) {
 		},
 	)
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for b.Loop() {
 		ioc.GetServices[Services](c)
@@ -6,7 +6,12 @@ import (
 	"fmt"
 	"reflect"
 	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ogiusek/ioc/v2"
 )
@@ -74,6 +79,13 @@ func TestContainerForDifferentTypes(t *testing.T) {
 		)
 	}
 
+	RunContainerTestsForType[ExInterface](
+		t,
+		nil,
+		&ExInterfaceImplementation{Prop: 1},
+		func(a, b ExInterface) bool { return marshal(a) == marshal(b) },
+	)
+
 	{
 		type WrapperInterface interface{ error }
 		a, b := &ExInterfaceImplementation{Prop: 1}, &ExInterfaceImplementation{Prop: 2}
@@ -268,7 +280,9 @@ func RunContainerTestsForType[Service any](
 		)
 	}
 
+	calls := 0
 	register := func(toggler *bool) Service {
+		calls++
 		defer func() { *toggler = !*toggler }()
 		if !*toggler {
 			return serviceA
@@ -306,6 +320,10 @@ func RunContainerTestsForType[Service any](
 		for range 10 {
 			test(c)
 		}
+
+		if calls != 1 {
+			t.Errorf("singleton service creator expected to be called once and was called %v times", calls)
+		}
 	})
 }
 
@@ -341,13 +359,38 @@ func TestInjectServicesError(t *testing.T) {
 		if r != nil {
 			afterPanic()
 		}
-		if r == nil || errors.Is(errors.New(r.(string)), ioc.ErrServiceIsntRegistered) {
+		if err, ok := r.(*ioc.Error); !ok || !errors.Is(err, ioc.ErrServiceIsntRegistered) {
 			t.Errorf("expected InjectServices to panic when service do not exist and didn't expect %v", r)
 		}
 	}()
 	ioc.GetServices[*Services](c)
 }
 
+func TestRecover(t *testing.T) {
+	type Service struct{}
+	run := func() (err error) {
+		defer ioc.Recover(&err)
+		ioc.Get[Service](ioc.NewContainer())
+		return nil
+	}
+
+	err := run()
+	var containerErr *ioc.Error
+	if !errors.As(err, &containerErr) || !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected recovered container error and got %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != "other" {
+			t.Errorf("expected Recover to panic again with other panics and got %v", r)
+		}
+	}()
+	func() (err error) {
+		defer ioc.Recover(&err)
+		panic("other")
+	}()
+}
+
 func TestNestedService(t *testing.T) {
 	type Service struct{ Val int }
 	type Wrapper struct{ Service Service }
@@ -415,3 +458,391 @@ func TestCircularDependencyDetectionSafety(t *testing.T) {
 	ioc.Get[ServiceA](c)
 	ioc.Get[ServiceB](c)
 }
+
+func TestRegisterDuplicate(t *testing.T) {
+	type Service struct{}
+	for name, pkg := range map[string]ioc.Pkg{
+		"unnamed": func(b ioc.Builder) {
+			ioc.Register(b, func(c ioc.Dic) Service { return Service{} })
+			ioc.Supply(b, Service{})
+		},
+		"named": func(b ioc.Builder) {
+			ioc.RegisterNamed(b, "a", func(c ioc.Dic) Service { return Service{} })
+			ioc.RegisterNamed(b, "a", func(c ioc.Dic) Service { return Service{} })
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ioc.TryNewContainer(pkg)
+			if !errors.Is(err, ioc.ErrServiceAlreadyRegistered) || !strings.Contains(err.Error(), "Service") {
+				t.Errorf("expected ErrServiceAlreadyRegistered naming the service and got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewContainerWithManyTypes(t *testing.T) {
+	values := make([]any, 1_000)
+	for i := range values {
+		values[i] = reflect.Zero(reflect.ArrayOf(i, reflect.TypeFor[struct{}]())).Interface()
+	}
+	c := ioc.NewContainer(func(b ioc.Builder) { ioc.Supply(b, values...) })
+
+	if services := c.Services(); len(services) != len(values) {
+		t.Errorf("expected %v services and got %v", len(values), len(services))
+	}
+	if _, err := ioc.TryGet[[999]struct{}](c); err != nil {
+		t.Errorf("expected supplied service and got %v", err)
+	}
+}
+
+func TestSupply(t *testing.T) {
+	type Service struct{ Val int }
+	var nilPointer *Service
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Supply(b, Service{Val: 1}, nilPointer, "text")
+	})
+
+	if service := ioc.Get[Service](c); service.Val != 1 {
+		t.Errorf("unexpected value expected %v and got %v", 1, service.Val)
+	}
+	if service := ioc.Get[*Service](c); service != nil {
+		t.Errorf("expected supplied nil pointer and got %v", service)
+	}
+	if text := ioc.Get[string](c); text != "text" {
+		t.Errorf("unexpected value expected %v and got %v", "text", text)
+	}
+}
+
+func TestSupplyUntypedNil(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			afterPanic()
+		} else {
+			t.Errorf("expected Supply to panic on untyped nil")
+		}
+	}()
+	ioc.NewContainer(func(b ioc.Builder) { ioc.Supply(b, nil) })
+}
+
+func TestProvide(t *testing.T) {
+	wrapped := 0
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide[ExInterface](b, nil)
+		ioc.Wrap(b, func(c ioc.Dic, s ExInterface) { wrapped++ })
+	})
+
+	if service := ioc.Get[ExInterface](c); service != nil {
+		t.Errorf("expected provided nil interface and got %v", service)
+	}
+	if service := ioc.Get[ioc.Lazy[ExInterface]](c)(); service != nil {
+		t.Errorf("expected provided nil interface and got %v", service)
+	}
+	var service ExInterface = &ExInterfaceImplementation{}
+	if err := c.Inject(&service); err != nil || service != nil {
+		t.Errorf("expected injected nil interface and got %v, %v", service, err)
+	}
+	if wrapped != 1 {
+		t.Errorf("expected wrap to be applied once and it was applied %v times", wrapped)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	type Registered struct{ Val int }
+	type Missing struct{ Val int }
+	type Services struct {
+		Registered ioc.Optional[Registered] `inject:""`
+		Missing    ioc.Optional[Missing]    `inject:""`
+		Sentry     ExInterface              `inject:",optional"`
+		Required   Registered               `inject:",optional"`
+	}
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Registered { return Registered{Val: 7} })
+	})
+
+	if registered := ioc.Get[ioc.Optional[Registered]](c); !registered.Ok() || registered.Value().Val != 7 {
+		t.Errorf("expected registered optional service and got %v", registered)
+	}
+	if missing, err := ioc.TryGet[ioc.Optional[Missing]](c); err != nil || missing.Ok() {
+		t.Errorf("expected missing optional service and got %v, %v", missing, err)
+	}
+
+	services := ioc.GetServices[Services](c)
+	if !services.Registered.Ok() || services.Registered.Value().Val != 7 {
+		t.Errorf("expected registered optional service and got %v", services.Registered)
+	}
+	if services.Missing.Ok() {
+		t.Errorf("expected missing optional service and got %v", services.Missing)
+	}
+	if services.Sentry != nil {
+		t.Errorf("expected optional field to be left with zero value and got %v", services.Sentry)
+	}
+	if services.Required.Val != 7 {
+		t.Errorf("expected optional field to be injected when service is registered and got %v", services.Required)
+	}
+}
+
+func TestOptionalFailedConstruction(t *testing.T) {
+	type Missing struct{}
+	type Sentry struct{}
+	type Handler struct {
+		Missing Missing `inject:""`
+	}
+	type Services struct {
+		Handler Handler `inject:",optional"`
+	}
+
+	c := ioc.NewContainer(ioc.LazyConstruction, ioc.WithResolver(ioc.StructResolver()), func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Sentry {
+			ioc.Get[Missing](c)
+			return Sentry{}
+		})
+	})
+
+	if sentry, err := ioc.TryGet[ioc.Optional[Sentry]](c); !errors.Is(err, ioc.ErrConstructionFailed) {
+		t.Errorf("expected construction failure of registered service and got %v, %v", sentry, err)
+	}
+	if _, err := ioc.TryGetServices[Services](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected resolver failure to be returned and got %v", err)
+	}
+}
+
+func TestInjectServicesTags(t *testing.T) {
+	type Service struct{ Val int }
+	type Other struct {
+		Service Service `inject:""`
+	}
+	type Services struct {
+		Other   Other    `inject:",embed"`
+		Pointer *Other   `inject:",embed"`
+		Named   Service  `inject:"name=named"`
+		Legacy  Service  `inject:"1"`
+		Plain   struct{} // not tagged
+	}
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Service { return Service{Val: 1} })
+		ioc.RegisterNamed(b, "named", func(c ioc.Dic) Service { return Service{Val: 2} })
+	})
+
+	services, err := ioc.TryGetServices[Services](c)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if services.Other.Service.Val != 1 || services.Pointer.Service.Val != 1 {
+		t.Errorf("embedded services aren't injected %v %v", services.Other, services.Pointer)
+	}
+	if services.Named.Val != 2 || ioc.GetNamed[Service](c, "named").Val != 2 {
+		t.Errorf("named service isn't injected %v", services.Named)
+	}
+	if services.Legacy.Val != 1 {
+		t.Errorf("legacy tag isn't injected %v", services.Legacy)
+	}
+
+	if _, err := ioc.TryGetServices[struct{ Other }](c); err != nil {
+		t.Errorf("struct without tagged fields shouldn't return error and got %v", err)
+	}
+}
+
+func TestInjectServicesErrorListsEveryField(t *testing.T) {
+	type Missing struct{}
+	type Other struct {
+		Missing Missing `inject:""`
+	}
+	type Services struct {
+		Other   Other   `inject:",embed"`
+		Missing Missing `inject:""`
+		Invalid Missing `inject:",unknown"`
+		Named   Missing `inject:"name=missing"`
+	}
+
+	_, err := ioc.TryGetServices[Services](ioc.NewContainer())
+	if !errors.Is(err, ioc.ErrServiceIsntRegistered) || !errors.Is(err, ioc.ErrInvalidInjectTag) {
+		t.Errorf("expected error to wrap original errors and got %v", err)
+	}
+	for _, path := range []string{"Services.Other.Missing", "Services.Missing", "Services.Invalid", "Services.Named"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("expected error to contain '%s' and got %v", path, err)
+		}
+	}
+}
+
+func TestInjectUnexportedFields(t *testing.T) {
+	type Service struct{ Val int }
+	type Services struct {
+		service Service `inject:""`
+	}
+	pkg := func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Service { return Service{Val: 1} })
+	}
+
+	if _, err := ioc.TryGetServices[Services](ioc.NewContainer(pkg)); !errors.Is(err, ioc.ErrUnexportedField) {
+		t.Errorf("expected ErrUnexportedField and got %v", err)
+	}
+
+	services, err := ioc.TryGetServices[Services](ioc.NewContainer(ioc.InjectUnexportedFields, pkg))
+	if err != nil || services.service.Val != 1 {
+		t.Errorf("expected unexported field to be injected and got %v, %v", services.service, err)
+	}
+}
+
+func TestFailedConstruction(t *testing.T) {
+	type ServiceA struct{ Err error }
+	type ServiceB struct{}
+	errCreator := errors.New("creator error")
+
+	newPkg := func(calls *int) ioc.Pkg {
+		return func(b ioc.Builder) {
+			// ServiceA is created first and ignores failure of ServiceB
+			ioc.Register(b, func(c ioc.Dic) ServiceA {
+				_, err := ioc.TryGet[ServiceB](c)
+				return ServiceA{Err: err}
+			})
+			ioc.Register(b, func(c ioc.Dic) ServiceB {
+				*calls++
+				if *calls == 1 {
+					panic(errCreator)
+				}
+				return ServiceB{}
+			})
+		}
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		calls := 0
+		defer func() {
+			r := recover()
+			err, _ := r.(error)
+			if !errors.Is(err, ioc.ErrConstructionFailed) || !errors.Is(err, errCreator) {
+				t.Errorf("expected cached construction error and got %v", r)
+			}
+			if errors.Is(err, ioc.ErrCircularDependency) {
+				t.Errorf("failed construction shouldn't be reported as circular dependency %v", err)
+			}
+			var panicErr *ioc.PanicError
+			if !errors.As(err, &panicErr) || len(panicErr.Stack) == 0 {
+				t.Errorf("expected error to contain panic stack and got %v", err)
+			}
+			if calls != 1 {
+				t.Errorf("expected creator to be called once and it was called %v times", calls)
+			}
+		}()
+		ioc.NewContainer(newPkg(&calls))
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		calls := 0
+		c := ioc.NewContainer(ioc.RetryFailedConstruction, newPkg(&calls))
+		if err := ioc.Get[ServiceA](c).Err; !errors.Is(err, errCreator) {
+			t.Errorf("expected first construction to fail and got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected creator to be called twice and it was called %v times", calls)
+		}
+	})
+}
+
+func TestRegisterDefault(t *testing.T) {
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterDefault(b, func(c ioc.Dic) string { return "default" })
+		ioc.RegisterDefault(b, func(c ioc.Dic) int { return 1 })
+		ioc.Register(b, func(c ioc.Dic) int { return 2 })
+	})
+
+	if text := ioc.Get[string](c); text != "default" {
+		t.Errorf("expected default service and got %v", text)
+	}
+	if number := ioc.Get[int](c); number != 2 {
+		t.Errorf("expected registered service to take precedence over default and got %v", number)
+	}
+}
+
+func TestLazyConstruction(t *testing.T) {
+	type Broken struct{}
+	type Healthy struct{}
+	type Named struct{}
+
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Broken { panic("broken") })
+		ioc.Register(b, func(c ioc.Dic) Healthy { return Healthy{} })
+		ioc.RegisterNamed(b, "x", func(c ioc.Dic) Named { return Named{} })
+	})
+	if _, err := ioc.TryGet[Healthy](c); err != nil {
+		t.Errorf("expected healthy service to be created and got %v", err)
+	}
+	if _, err := ioc.TryGet[Broken](c); !errors.Is(err, ioc.ErrConstructionFailed) {
+		t.Errorf("expected ErrConstructionFailed on retrieval and got %v", err)
+	}
+	services := c.Services()
+	expected := []reflect.Type{reflect.TypeFor[Broken](), reflect.TypeFor[Healthy]()}
+	if !slices.Equal(services, expected) {
+		t.Errorf("expected %v services and got %v", expected, services)
+	}
+}
+
+func TestLazyConstructionParallel(t *testing.T) {
+	type Repo struct{}
+	type Service struct{ Repo Repo }
+
+	var created atomic.Int32
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Repo {
+			created.Add(1)
+			time.Sleep(time.Millisecond)
+			return Repo{}
+		})
+		ioc.Register(b, func(c ioc.Dic) Service { return Service{Repo: ioc.Get[Repo](c)} })
+	})
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := ioc.TryGet[Service](c); err != nil {
+				t.Errorf("expected concurrently created service and got %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := ioc.TryGet[Repo](c); err != nil {
+				t.Errorf("expected concurrently created service and got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created := created.Load(); created != 1 {
+		t.Errorf("expected service to be created once and it was created %v times", created)
+	}
+}
+
+func TestGetServicesPlan(t *testing.T) {
+	type Logger interface{ Log() string }
+	type Services struct {
+		Logger Logger  `inject:""`
+		Config *string `inject:""`
+		Count  int     `inject:""`
+	}
+
+	config := "config"
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Logger { return stdLogger{} })
+		ioc.Provide(b, &config)
+		ioc.Provide(b, 7)
+	})
+
+	for range 2 {
+		services := ioc.GetServices[Services](c)
+		if services.Logger.Log() != "std" || services.Config != &config || services.Count != 7 {
+			t.Errorf("expected services injected by cached plan and got %v", services)
+		}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { ioc.GetServices[Services](c) }); allocs != 0 {
+		t.Errorf("expected GetServices not to allocate and got %v allocations", allocs)
+	}
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Log() string { return "std" }
@@ -0,0 +1,110 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvResolver resolves unregistered config structs (or pointers to them) which have at least one field with `env` tag.
+// Fields are read from environment variables prefixed with prefix.
+// Tag is a variable name optionally followed by `required` option. Missing variables leave fields with zero values.
+// Supported fields are strings, bools, numbers and time.Duration
+//
+// Example:
+//
+//	type Config struct {
+//	    Addr    string        `env:"ADDR,required"`
+//	    Timeout time.Duration `env:"TIMEOUT"`
+//	}
+//
+//	c := ioc.NewContainer(Pkg, ioc.WithResolver(ioc.EnvResolver("APP_")))
+func EnvResolver(prefix string) Resolver {
+	return ResolverFunc(func(c Dic, t reflect.Type) (any, bool, error) {
+		structType := t
+		if t.Kind() == reflect.Pointer {
+			structType = t.Elem()
+		}
+		if structType.Kind() != reflect.Struct || !hasEnvTag(structType) {
+			return nil, false, nil
+		}
+		value := reflect.New(structType)
+		if err := readEnv(value.Elem(), prefix); err != nil {
+			return nil, false, err
+		}
+		if t.Kind() == reflect.Pointer {
+			return value.Interface(), true, nil
+		}
+		return value.Elem().Interface(), true, nil
+	})
+}
+
+func hasEnvTag(t reflect.Type) bool {
+	for i := range t.NumField() {
+		if _, ok := t.Field(i).Tag.Lookup("env"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readEnv sets fields with `env` tag. Returns error for every field which couldn't be read
+func readEnv(config reflect.Value, prefix string) error {
+	var errs []error
+	t := config.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		name = prefix + name
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if options == "required" {
+				errs = append(errs, errors.Join(ErrInvalidEnv, fmt.Errorf("field '%s': variable '%s' is required", field.Name, name)))
+			}
+			continue
+		}
+		if err := setEnv(config.Field(i), value); err != nil {
+			errs = append(errs, errors.Join(ErrInvalidEnv, fmt.Errorf("field '%s': variable '%s': %w", field.Name, name, err)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setEnv(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeFor[time.Duration]() {
+		d, err := time.ParseDuration(value)
+		field.SetInt(int64(d))
+		return err
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		field.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		field.SetInt(i)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		field.SetUint(u)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		field.SetFloat(f)
+		return err
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type().String())
+	}
+	return nil
+}
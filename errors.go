@@ -1,11 +1,82 @@
 package ioc
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
 
 var (
 	ErrIsntPointer         error = errors.New("isn't a pointer")
 	ErrIsntPointerToStruct error = errors.New("isn't a pointer to a struct")
+	ErrIsntFunction        error = errors.New("isn't a function")
+
+	ErrServiceIsntRegistered      error = errors.New("service isn't registered")
+	ErrServiceAlreadyRegistered   error = errors.New("service is already registered")
+	ErrInvalidServiceRegistration error = errors.New("invalid service registration")
+	ErrCircularDependency         error = errors.New("circular dependency")
+	ErrConstructionFailed         error = errors.New("service construction failed")
+
+	ErrInvalidModule  error = errors.New("invalid module")
+	ErrModuleConflict error = errors.New("module conflict")
+	ErrPrivateService error = errors.New("service is private to other module")
 
-	ErrServiceIsntRegistered error = errors.New("service isn't registered")
-	ErrCircularDependency    error = errors.New("circular dependency")
+	ErrInvalidInjectTag error = errors.New("invalid inject tag")
+	ErrUnexportedField  error = errors.New("unexported field cannot be injected without InjectUnexportedFields option")
+
+	ErrSealed error = errors.New("service is retrieved from a sealed container")
+
+	ErrInvalidEnv error = errors.New("invalid environment variable")
 )
+
+// PanicError is a recovered panic of a service creator
+type PanicError struct {
+	// Service is a type of the service which creator panicked
+	Service reflect.Type
+	Value   any
+	Stack   []byte
+}
+
+// Error doesn't contain the stack so nested failures stay readable
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns panic value when it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Error is a value container panics with
+type Error struct {
+	Err error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+func throw(err error) {
+	panic(&Error{Err: err})
+}
+
+// Recover converts container panic into an error. It has to be deferred:
+//
+//	func run() (err error) {
+//	    defer ioc.Recover(&err)
+//	    services := ioc.GetServices[Services](c)
+//	    // ...
+//	}
+//
+// Panics which aren't *Error are panicked again
+func Recover(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	e, ok := r.(*Error)
+	if !ok {
+		panic(r)
+	}
+	*err = e
+}
@@ -0,0 +1,50 @@
+package ioc
+
+import "sync/atomic"
+
+// Factory creates new instance of Service from arguments supplied by the caller on every call.
+// Dependencies of the service are supplied by the container. Factory is registered with RegisterFactory
+// and retrieved or injected like other services
+type Factory[Args, Service any] func(args Args) Service
+
+// RegisterFactory registers Factory[Args, Service] with singleton lifetime.
+// ctor is called on every factory call with the container and arguments of the call.
+// Wraps of Service are applied to every instance even when Service itself isn't registered
+//
+// Example:
+//
+//	type HandlerArgs struct{ UserID string }
+//
+//	ioc.RegisterFactory(b, func(c ioc.Dic, args HandlerArgs) *Handler {
+//	    return &Handler{Repo: ioc.Get[Repo](c), UserID: args.UserID}
+//	})
+//
+//	type Server struct {
+//	    Handlers ioc.Factory[HandlerArgs, *Handler] `inject:""`
+//	}
+//
+//	handler := server.Handlers(HandlerArgs{UserID: id})
+func RegisterFactory[Args, Service any](b Builder, ctor func(c Dic, args Args) Service) {
+	key := typeKey[Service]()
+	Register(b, func(c Dic) Factory[Args, Service] {
+		wraps := c.wraps(key)
+		return func(args Args) Service {
+			// retrievals of ctor are wiring like retrievals of creators.
+			// Instances keep container which is reported by sealed container after ctor returns
+			var calling atomic.Bool
+			calling.Store(true)
+			service := ctor(c.during(&calling), args)
+			calling.Store(false)
+			wraps(c.during(wiring), service)
+			return service
+		}
+	})
+}
+
+// wraps returns wraps of the service. It returns noWraps when service isn't wrapped
+func (c Dic) wraps(key serviceID) func(Dic, any) {
+	if wraps, ok := c.c.wraps[key]; ok {
+		return wraps
+	}
+	return noWraps
+}
@@ -0,0 +1,44 @@
+package ioc_test
+
+import (
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type factoryRepo struct{}
+type factoryArgs struct{ UserID string }
+type factoryHandler struct {
+	Repo    *factoryRepo
+	UserID  string
+	Wrapped int
+}
+
+func TestRegisterFactory(t *testing.T) {
+	repo := &factoryRepo{}
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, repo)
+		ioc.RegisterFactory(b, func(c ioc.Dic, args factoryArgs) *factoryHandler {
+			return &factoryHandler{Repo: ioc.Get[*factoryRepo](c), UserID: args.UserID}
+		})
+		ioc.Wrap(b, func(c ioc.Dic, s *factoryHandler) { s.Wrapped++ })
+	})
+
+	var services struct {
+		Handlers ioc.Factory[factoryArgs, *factoryHandler] `inject:""`
+	}
+	if err := c.InjectServices(&services); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	first := services.Handlers(factoryArgs{UserID: "a"})
+	second := services.Handlers(factoryArgs{UserID: "b"})
+	if first == second {
+		t.Errorf("expected new instance on every call")
+	}
+	if first.Repo != repo || first.UserID != "a" || second.UserID != "b" {
+		t.Errorf("unexpected instances %v %v", first, second)
+	}
+	if first.Wrapped != 1 || second.Wrapped != 1 {
+		t.Errorf("expected wraps to be applied once to every instance, got %v and %v", first.Wrapped, second.Wrapped)
+	}
+}
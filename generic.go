@@ -0,0 +1,98 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+// RegisterGeneric registers factory for every instantiation of generic type Family with singleton lifetime.
+// Family is any instantiation of the generic type, e.g. `Repository[any]` or `*Repository[any]`
+// and it matches instantiations with the same pointer-ness only.
+// factory receives requested instantiation and returns its instance.
+// Instances are created on the first retrieval and cached per instantiation like resolved services,
+// so they aren't wrapped nor closed. Registered instantiations take precedence over the factory
+//
+// Go reflection doesn't expose type arguments so factory can construct instance
+// with a method of the generic type which knows its type parameters:
+//
+//	type Repository[T any] struct{ db *DB }
+//
+//	func (Repository[T]) New(c ioc.Dic) any { return &Repository[T]{db: ioc.Get[*DB](c)} }
+//
+//	ioc.RegisterGeneric[*Repository[any]](b, func(c ioc.Dic, t reflect.Type) any {
+//	    return reflect.Zero(t.Elem()).Interface().(interface{ New(ioc.Dic) any }).New(c)
+//	})
+//
+//	users := ioc.Get[*Repository[User]](c)
+func RegisterGeneric[Family any](b Builder, factory func(c Dic, t reflect.Type) any) {
+	familyType := reflect.TypeFor[Family]()
+	family, ok := genericFamily(familyType)
+	if !ok {
+		throw(errors.Join(
+			ErrInvalidServiceRegistration,
+			fmt.Errorf("type '%s' isn't an instantiation of a generic type", familyType.String()),
+		))
+	}
+	if _, ok := b.b.generics[family]; ok {
+		throw(errors.Join(
+			ErrServiceAlreadyRegistered,
+			fmt.Errorf("generic service '%s' is already registered", family),
+		))
+	}
+	if b.b.generics == nil {
+		b.b.generics = map[string]generic{}
+	}
+	b.b.generics[family] = generic{factory: factory, module: b.b.module}
+}
+
+type generic struct {
+	factory func(c Dic, t reflect.Type) any
+	// module which registered the generic service
+	module string
+}
+
+// genericFamily returns name of the generic type t is instantiated from, e.g. `*pkg/path.Repository`
+func genericFamily(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Pointer {
+		family, ok := genericFamily(t.Elem())
+		return "*" + family, ok
+	}
+	name, _, ok := strings.Cut(t.Name(), "[")
+	if !ok {
+		return "", false
+	}
+	return t.PkgPath() + "." + name, true
+}
+
+// genericResolver resolves instantiations of registered generic types
+func genericResolver(generics map[string]generic) Resolver {
+	return ResolverFunc(func(c Dic, t reflect.Type) (instance any, ok bool, err error) {
+		family, ok := genericFamily(t)
+		if !ok {
+			return nil, false, nil
+		}
+		g, ok := generics[family]
+		if !ok {
+			return nil, false, nil
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.Join(
+					ErrConstructionFailed,
+					fmt.Errorf("generic service of type '%s' failed to construct: %w", t.String(), &PanicError{Service: t, Value: r, Stack: debug.Stack()}),
+				)
+			}
+		}()
+		instance = g.factory(c.in(g.module), t)
+		if instance != nil && !reflect.TypeOf(instance).AssignableTo(t) {
+			return nil, false, errors.Join(
+				ErrInvalidServiceRegistration,
+				fmt.Errorf("factory of generic service '%s' returned '%T' for '%s'", family, instance, t.String()),
+			)
+		}
+		return instance, true, nil
+	})
+}
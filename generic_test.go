@@ -0,0 +1,157 @@
+package ioc_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type genericDB struct{}
+type genericUser struct{}
+type genericOrder struct{}
+
+type genericRepository[T any] struct{ DB *genericDB }
+
+func (genericRepository[T]) New(c ioc.Dic) any {
+	return &genericRepository[T]{DB: ioc.Get[*genericDB](c)}
+}
+
+func newGenericRepository(c ioc.Dic, t reflect.Type) any {
+	return reflect.Zero(t.Elem()).Interface().(interface{ New(ioc.Dic) any }).New(c)
+}
+
+func TestRegisterGeneric(t *testing.T) {
+	calls := 0
+	db := &genericDB{}
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, db)
+		ioc.RegisterGeneric[*genericRepository[any]](b, func(c ioc.Dic, t reflect.Type) any {
+			calls++
+			return newGenericRepository(c, t)
+		})
+	})
+
+	users := ioc.Get[*genericRepository[genericUser]](c)
+	orders := ioc.Get[*genericRepository[genericOrder]](c)
+	if users.DB != db || orders.DB != db {
+		t.Errorf("expected repositories to be injected")
+	}
+	if ioc.Get[*genericRepository[genericUser]](c) != users || calls != 2 {
+		t.Errorf("expected singleton per instantiation and factory was called %v times", calls)
+	}
+	if _, err := ioc.TryGet[genericRepository[genericUser]](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected family not to match value instantiation and got %v", err)
+	}
+}
+
+func TestRegisterGenericParallel(t *testing.T) {
+	var calls atomic.Int32
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.Provide(b, &genericDB{})
+		ioc.RegisterGeneric[*genericRepository[any]](b, func(c ioc.Dic, t reflect.Type) any {
+			calls.Add(1)
+			time.Sleep(time.Millisecond)
+			return newGenericRepository(c, t)
+		})
+	})
+
+	var wg sync.WaitGroup
+	repos := make([]*genericRepository[genericUser], 10)
+	for i := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo, err := ioc.TryGet[*genericRepository[genericUser]](c)
+			if err != nil {
+				t.Errorf("expected concurrently resolved service and got %v", err)
+			}
+			repos[i] = repo
+		}()
+	}
+	wg.Wait()
+	if calls := calls.Load(); calls != 1 {
+		t.Errorf("expected service to be resolved once and it was resolved %v times", calls)
+	}
+	for _, repo := range repos {
+		if repo != repos[0] {
+			t.Errorf("expected every goroutine to get the same service")
+		}
+	}
+}
+
+func TestRegisterGenericRegisteredInstantiation(t *testing.T) {
+	registered := &genericRepository[genericUser]{}
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, &genericDB{})
+		ioc.Provide(b, registered)
+		ioc.RegisterGeneric[*genericRepository[any]](b, newGenericRepository)
+	})
+
+	if ioc.Get[*genericRepository[genericUser]](c) != registered {
+		t.Errorf("expected registered instantiation to take precedence")
+	}
+}
+
+func TestRegisterGenericInvalid(t *testing.T) {
+	t.Run("not generic", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ioc.ErrInvalidServiceRegistration) {
+				t.Errorf("expected ErrInvalidServiceRegistration and got %v", err)
+			}
+		}()
+		ioc.NewContainer(func(b ioc.Builder) {
+			ioc.RegisterGeneric[*genericDB](b, newGenericRepository)
+		})
+	})
+	t.Run("duplicate", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ioc.ErrServiceAlreadyRegistered) {
+				t.Errorf("expected ErrServiceAlreadyRegistered and got %v", err)
+			}
+		}()
+		ioc.NewContainer(func(b ioc.Builder) {
+			ioc.RegisterGeneric[*genericRepository[any]](b, newGenericRepository)
+			ioc.RegisterGeneric[*genericRepository[int]](b, newGenericRepository)
+		})
+	})
+	t.Run("wrong type", func(t *testing.T) {
+		c := ioc.NewContainer(func(b ioc.Builder) {
+			ioc.RegisterGeneric[*genericRepository[any]](b, func(c ioc.Dic, t reflect.Type) any { return &genericDB{} })
+		})
+		if _, err := ioc.TryGet[*genericRepository[genericUser]](c); !errors.Is(err, ioc.ErrInvalidServiceRegistration) {
+			t.Errorf("expected ErrInvalidServiceRegistration and got %v", err)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		c := ioc.NewContainer(func(b ioc.Builder) {
+			ioc.RegisterGeneric[*genericRepository[any]](b, newGenericRepository) // genericDB isn't registered
+		})
+		if _, err := ioc.TryGet[*genericRepository[genericUser]](c); !errors.Is(err, ioc.ErrConstructionFailed) || !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+			t.Errorf("expected ErrConstructionFailed and got %v", err)
+		}
+	})
+}
+
+func TestRegisterGenericAdapters(t *testing.T) {
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, &genericDB{})
+		ioc.RegisterGeneric[*genericRepository[any]](b, newGenericRepository)
+	})
+
+	users := ioc.Get[*genericRepository[genericUser]](c)
+	lazy, err := ioc.TryGet[ioc.Lazy[*genericRepository[genericUser]]](c)
+	if err != nil || lazy() != users {
+		t.Errorf("expected lazy getter of instantiation and got %v", err)
+	}
+	getter, err := ioc.TryGet[func() *genericRepository[genericUser]](c)
+	if err != nil || getter() != users {
+		t.Errorf("expected getter of instantiation and got %v", err)
+	}
+}
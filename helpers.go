@@ -1,27 +1,55 @@
 package ioc
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 )
 
-// Transient is just a factory which can be registered
+// Transient is a factory creating new instance of the registered service on every call.
+// It is synthesized for every registered service and can be registered to replace synthesized factory
 type Transient[Service any] func() Service
 
 //
 
-// For every service its lazy getter is automatically registered
+// Lazy getter is synthesized for every registered service
 type Lazy[Service any] func() Service
 
 //
 
+// Optional is a service which may not be registered.
+// It can be retrieved and injected even when Service isn't registered
+type Optional[Service any] struct {
+	value Service
+	ok    bool
+}
+
+// returns service or its zero value when service isn't registered
+func (o Optional[Service]) Value() Service { return o.value }
+
+// returns true when service is registered
+func (o Optional[Service]) Ok() bool { return o.ok }
+
+//
+
 // pkg is an interface recommended to use
 type Pkg func(b Builder)
 
 func NewPkg(r func(b Builder)) Pkg { return r }
+
+// NewPkgT creates parametrized package. Packages created from the same function with equal configs
+// are registered once like modules, packages with different configs are registered separately
 func NewPkgT[Config any](r func(Builder, Config)) func(Config) Pkg {
-	return func(c Config) Pkg { return func(b Builder) { r(b, c) } }
+	registerID := funcID(r)
+	return func(c Config) Pkg {
+		return func(b Builder) {
+			if b.includedPkgT(registerID, c) {
+				return
+			}
+			r(b, c)
+		}
+	}
 }
 
 //
@@ -37,10 +65,16 @@ type ServiceRegistry[Key, Service any] interface {
 
 // errors
 func AlreadyRegisteredInServiceRegistry[Service any](key any) {
-	log.Panicf("registered service '%s' key '%s' already exists", reflect.TypeFor[Service]().String(), key)
+	throw(errors.Join(
+		ErrServiceAlreadyRegistered,
+		fmt.Errorf("registered service '%s' key '%s' already exists", reflect.TypeFor[Service]().String(), key),
+	))
 }
 func MissingKeyInServiceRegistry[Service any](key any) {
-	log.Panicf("service of type '%s' with key '%s' is not registered", reflect.TypeFor[Service]().String(), key)
+	throw(errors.Join(
+		ErrServiceIsntRegistered,
+		fmt.Errorf("service of type '%s' with key '%s' is not registered", reflect.TypeFor[Service]().String(), key),
+	))
 }
 
 // map service registry
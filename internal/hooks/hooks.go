@@ -0,0 +1,6 @@
+// Package hooks exposes container internals to ioctest without adding them to the public ioc API
+package hooks
+
+// ResolveRegistration returns service of ioc.Registration from ioc.Dic on behalf of the module which registered it
+// so private services are resolved too. It is set by ioc
+var ResolveRegistration func(c, registration any) (any, error)
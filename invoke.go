@@ -0,0 +1,92 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// In is embedded in parameter structs. Fields of parameter structs with `inject` tag are injected
+// like in GetServices instead of the struct being resolved as a service.
+// Parameter structs are supported by Invoke and RegisterCtor
+//
+// Example:
+//
+//	type Params struct {
+//	    ioc.In
+//	    Logger Logger `inject:""`
+//	    Repo   Repo   `inject:",optional"`
+//	}
+//
+//	ioc.Invoke(c, func(p Params) { ... })
+type In struct{}
+
+var inType = reflect.TypeFor[In]()
+
+// Invoke calls fn with parameters resolved from the container and returns its results.
+// When the last result of fn is an error it is returned as an error instead of a result
+//
+// Example:
+//
+//	func main() {
+//	    c := ioc.NewContainer(app.Pkg)
+//	    if _, err := ioc.Invoke(c, run); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+//
+//	func run(server *http.Server, logger Logger) error
+func Invoke(c Dic, fn any) ([]any, error) {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func || f.IsNil() {
+		return nil, errors.Join(ErrIsntFunction, fmt.Errorf("expected function, got %T", fn))
+	}
+	fnType := f.Type()
+	if fnType.IsVariadic() {
+		return nil, errors.Join(ErrIsntFunction, fmt.Errorf("function '%s' cannot be variadic", fnType.String()))
+	}
+	args, err := c.tryResolveArgs(fnType)
+	if err != nil {
+		return nil, err
+	}
+	out := f.Call(args)
+	if len(out) != 0 && fnType.Out(len(out)-1) == errorType {
+		err, _ = out[len(out)-1].Interface().(error)
+		out = out[:len(out)-1]
+	}
+	res := make([]any, len(out))
+	for i, value := range out {
+		res[i] = value.Interface()
+	}
+	return res, err
+}
+
+// Invoke1 works like Invoke for fn returning R or (R, error)
+func Invoke1[R any](c Dic, fn any) (R, error) {
+	var r R
+	if fnType := reflect.TypeOf(fn); fnType == nil || fnType.Kind() != reflect.Func || fnType.NumOut() == 0 || fnType.Out(0) != reflect.TypeFor[R]() ||
+		fnType.NumOut() > 2 || (fnType.NumOut() == 2 && fnType.Out(1) != errorType) {
+		return r, errors.Join(ErrIsntFunction, fmt.Errorf("expected function returning '%s' or ('%s', error), got %T", reflect.TypeFor[R]().String(), reflect.TypeFor[R]().String(), fn))
+	}
+	res, err := Invoke(c, fn)
+	if len(res) == 1 {
+		r, _ = res[0].(R) // nil interfaces are valid results
+	}
+	return r, err
+}
+
+// isParams returns true when t is a parameter struct embedding In
+func isParams(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range t.NumField() {
+		if field := t.Field(i); field.Anonymous && field.Type == inType {
+			return true
+		}
+	}
+	return false
+}
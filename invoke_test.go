@@ -0,0 +1,57 @@
+package ioc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func TestInvoke(t *testing.T) {
+	type Logger struct{ Name string }
+	type Repo struct{ Name string }
+	type Missing struct{}
+	type Params struct {
+		ioc.In
+		Repo    Repo    `inject:""`
+		Missing Missing `inject:",optional"`
+	}
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, Logger{Name: "logger"})
+		ioc.Provide(b, Repo{Name: "repo"})
+	})
+
+	res, err := ioc.Invoke(c, func(logger Logger, p Params, pointer *Params) (string, string, error) {
+		return logger.Name, p.Repo.Name + pointer.Repo.Name, nil
+	})
+	if err != nil || len(res) != 2 || res[0] != "logger" || res[1] != "reporepo" {
+		t.Errorf("expected resolved parameters and got %v %v", res, err)
+	}
+
+	failure := errors.New("failure")
+	if _, err := ioc.Invoke(c, func(Logger) error { return failure }); err != failure {
+		t.Errorf("expected trailing error to be returned and got %v", err)
+	}
+	if _, err := ioc.Invoke(c, func(Missing) {}); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected ErrServiceIsntRegistered and got %v", err)
+	}
+	if _, err := ioc.Invoke(c, Logger{}); !errors.Is(err, ioc.ErrIsntFunction) {
+		t.Errorf("expected ErrIsntFunction and got %v", err)
+	}
+}
+
+func TestInvoke1(t *testing.T) {
+	type Repo struct{ Name string }
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Provide(b, Repo{Name: "repo"})
+	})
+
+	name, err := ioc.Invoke1[string](c, func(r Repo) (string, error) { return r.Name, nil })
+	if err != nil || name != "repo" {
+		t.Errorf("expected typed result and got %v %v", name, err)
+	}
+	if _, err := ioc.Invoke1[int](c, func(r Repo) string { return r.Name }); !errors.Is(err, ioc.ErrIsntFunction) {
+		t.Errorf("expected ErrIsntFunction for different result type and got %v", err)
+	}
+}
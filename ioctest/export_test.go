@@ -0,0 +1,3 @@
+package ioctest
+
+var Resolve = resolve
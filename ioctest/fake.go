@@ -0,0 +1,93 @@
+package ioctest
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+// Call is a method call recorded by a fake
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Fake records calls. It is embedded by fakes generated with iocfake:
+//
+//	//go:generate go run github.com/ogiusek/ioc/v2/cmd/iocfake -type Mailer
+type Fake struct {
+	mutex sync.Mutex
+	calls []Call
+}
+
+// Record records call of the method
+func (f *Fake) Record(method string, args ...any) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
+
+func (f *Fake) recorded() []Call {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// recorder is implemented by types embedding Fake
+type recorder interface {
+	recorded() []Call
+}
+
+var (
+	fakesMutex sync.Mutex
+	fakes      = map[reflect.Type]func() any{}
+)
+
+// RegisterFake registers constructor of the Service fake used by WithFakes.
+// It is called by code generated with iocfake in init
+func RegisterFake[Service any](newFake func() Service) {
+	fakesMutex.Lock()
+	defer fakesMutex.Unlock()
+	fakes[reflect.TypeFor[Service]()] = func() any { return newFake() }
+}
+
+// WithFakes is an option resolving unregistered interfaces to their registered fakes.
+// Every interface is resolved to a single fake per container so calls can be asserted with Calls
+//
+// Example:
+//
+//	c := ioctest.New(t, []ioc.Pkg{app.Pkg}, ioctest.WithFakes())
+//	ioc.Get[app.Signup](c).Run()
+//	calls := ioctest.Calls[app.Mailer](c)
+func WithFakes() ioc.Pkg {
+	return ioc.WithResolver(FakeResolver())
+}
+
+// FakeResolver resolves unregistered interfaces to their registered fakes
+func FakeResolver() ioc.Resolver {
+	return ioc.ResolverFunc(func(c ioc.Dic, t reflect.Type) (any, bool, error) {
+		if t.Kind() != reflect.Interface {
+			return nil, false, nil
+		}
+		fakesMutex.Lock()
+		newFake, ok := fakes[t]
+		fakesMutex.Unlock()
+		if !ok {
+			return nil, false, nil
+		}
+		return newFake(), true, nil
+	})
+}
+
+// Calls returns calls recorded by the Service fake.
+// Panics when Service isn't a fake
+func Calls[Service any](c ioc.Dic) []Call {
+	r, ok := any(ioc.Get[Service](c)).(recorder)
+	if !ok {
+		panic(fmt.Sprintf("service of type '%s' isn't a fake", reflect.TypeFor[Service]().String()))
+	}
+	return r.recorded()
+}
@@ -0,0 +1,78 @@
+// Package ioctest creates ioc containers for tests.
+//
+// Containers are created from production packages with test overrides applied,
+// wiring failures fail the test with a readable report instead of panicking
+// and containers are closed on test cleanup.
+package ioctest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+// New creates container from packages and overrides.
+// Overrides are applied after all packages so they replace services registered by packages.
+// Test fails with a wiring report when container cannot be created.
+// Container is closed on test cleanup
+//
+// Example:
+//
+//	c := ioctest.New(t, []ioc.Pkg{app.Pkg}, ioctest.Override[Mailer](&fakeMailer{}))
+func New(t testing.TB, pkgs []ioc.Pkg, overrides ...ioc.Pkg) ioc.Dic {
+	t.Helper()
+	c, err := ioc.TryNewContainer(append(slices.Clone(pkgs), overrides...)...)
+	if err != nil {
+		t.Fatalf("%s", report(err))
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("closing container: %v", err)
+		}
+	})
+	return c
+}
+
+// Override returns package replacing Service with value
+func Override[Service any](value Service) ioc.Pkg {
+	return func(b ioc.Builder) {
+		ioc.Override(b, func(c ioc.Dic) Service { return value })
+	}
+}
+
+// resolutionPath returns services which were being created when err occurred.
+// Last element is the service which creator failed and its stack
+func resolutionPath(err error) ([]string, []byte) {
+	var path []string
+	var stack []byte
+	var panicErr *ioc.PanicError
+	for errors.As(err, &panicErr) {
+		path = append(path, panicErr.Service.String())
+		stack = panicErr.Stack
+		err = panicErr.Unwrap()
+	}
+	return path, stack
+}
+
+// report describes wiring failure
+func report(err error) string {
+	var res strings.Builder
+	res.WriteString("container wiring failed\n")
+	path, stack := resolutionPath(err)
+	if len(path) != 0 {
+		fmt.Fprintf(&res, "resolution path: %s\n", strings.Join(path, " -> "))
+	}
+	fmt.Fprintf(&res, "error:\n%s\n", indent(err.Error()))
+	if len(stack) != 0 {
+		fmt.Fprintf(&res, "stack of the failed creator:\n%s", indent(string(stack)))
+	}
+	return res.String()
+}
+
+func indent(text string) string {
+	return "\t" + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n\t")
+}
@@ -0,0 +1,179 @@
+package ioctest_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+	"github.com/ogiusek/ioc/v2/ioctest"
+)
+
+type Mailer interface{ Send() string }
+type smtpMailer struct{}
+type fakeMailer struct{}
+
+func (smtpMailer) Send() string { return "smtp" }
+func (fakeMailer) Send() string { return "fake" }
+
+type Db struct{ closed bool }
+
+func (db *Db) Close() error {
+	db.closed = true
+	return nil
+}
+
+type App struct {
+	Mailer Mailer `inject:""`
+	Db     *Db    `inject:""`
+}
+
+var db = &Db{}
+
+var appPkg = ioc.NewPkg(func(b ioc.Builder) {
+	ioc.Register(b, func(c ioc.Dic) Mailer { return smtpMailer{} })
+	ioc.Register(b, func(c ioc.Dic) *Db { return db })
+	ioc.Register(b, func(c ioc.Dic) App { return ioc.GetServices[App](c) })
+})
+
+func TestNew(t *testing.T) {
+	t.Run("override", func(t *testing.T) {
+		c := ioctest.New(t, []ioc.Pkg{appPkg}, ioctest.Override[Mailer](fakeMailer{}))
+		if sent := ioc.Get[App](c).Mailer.Send(); sent != "fake" {
+			t.Errorf("expected overridden mailer and got %v", sent)
+		}
+	})
+	if !db.closed {
+		t.Errorf("expected container to be closed on test cleanup")
+	}
+}
+
+// recorder is testing.TB recording fatal messages
+type recorder struct {
+	testing.TB
+	fatal string
+}
+
+func (r *recorder) Helper()        {}
+func (r *recorder) Cleanup(func()) {}
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestNewReport(t *testing.T) {
+	type Config struct{}
+	type Repo struct{}
+	type Service struct{}
+
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ioctest.New(r, []ioc.Pkg{func(b ioc.Builder) {
+			ioc.Register(b, func(c ioc.Dic) Service { ioc.Get[Repo](c); return Service{} })
+			ioc.Register(b, func(c ioc.Dic) Repo { ioc.Get[Config](c); return Repo{} })
+		}})
+	}()
+	<-done
+
+	for _, expected := range []string{
+		"container wiring failed",
+		"resolution path: ioctest_test.Service -> ioctest_test.Repo",
+		"service of type 'ioctest_test.Config' is not registered",
+		"stack of the failed creator",
+	} {
+		if !strings.Contains(r.fatal, expected) {
+			t.Errorf("expected report to contain '%s' and got\n%s", expected, r.fatal)
+		}
+	}
+}
+
+type Clock interface{ Now() int }
+
+// ClockFake is written the way iocfake generates fakes
+type ClockFake struct {
+	ioctest.Fake
+	NowFunc func() int
+}
+
+func (f *ClockFake) Now() int {
+	f.Fake.Record("Now")
+	if f.NowFunc != nil {
+		return f.NowFunc()
+	}
+	var r0 int
+	return r0
+}
+
+func init() {
+	ioctest.RegisterFake(func() Clock { return &ClockFake{} })
+}
+
+type Signup struct {
+	Clock Clock `inject:""`
+}
+
+func TestWithFakes(t *testing.T) {
+	c := ioctest.New(t, []ioc.Pkg{func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Signup { return ioc.GetServices[Signup](c) })
+	}}, ioctest.WithFakes())
+
+	if now := ioc.Get[Signup](c).Clock.Now(); now != 0 {
+		t.Errorf("expected unconfigured fake to return zero value and got %v", now)
+	}
+	ioc.Get[Clock](c).(*ClockFake).NowFunc = func() int { return 7 }
+	if now := ioc.Get[Clock](c).Now(); now != 7 {
+		t.Errorf("expected configured fake and got %v", now)
+	}
+
+	calls := ioctest.Calls[Clock](c)
+	if len(calls) != 2 || calls[0].Method != "Now" {
+		t.Errorf("expected two recorded calls of Now and got %v", calls)
+	}
+	if _, err := ioc.TryGet[Mailer](c); err == nil {
+		t.Errorf("expected interface without fake to stay unregistered")
+	}
+}
+
+func TestAssertEachResolvable(t *testing.T) {
+	ioctest.AssertEachResolvable(t, appPkg)
+}
+
+func TestResolveReport(t *testing.T) {
+	type Config struct{}
+	type Secret struct{}
+	type Broken struct{}
+	type Healthy struct{}
+
+	pkg := func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Broken { ioc.Get[Config](c); return Broken{} })
+		ioc.Register(b, func(c ioc.Dic) Healthy { return Healthy{} })
+		ioc.RegisterNamed(b, "broken", func(c ioc.Dic) Healthy { ioc.Get[Config](c); return Healthy{} })
+		ioc.NewModule("db", func(b ioc.Builder) {
+			ioc.RegisterPrivate(b, func(c ioc.Dic) Secret { return Secret{} })
+			ioc.RegisterPrivate(b, func(c ioc.Dic) *Broken { ioc.Get[Secret](c); ioc.Get[Config](c); return &Broken{} })
+		}).Pkg()(b)
+	}
+	c := ioc.NewContainer(ioc.LazyConstruction, pkg)
+	var failed []string
+	for _, registration := range c.Registrations() {
+		if err := ioctest.Resolve([]ioc.Pkg{pkg}, registration); err != nil {
+			if !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+				t.Errorf("expected ErrServiceIsntRegistered and got %v", err)
+			}
+			failed = append(failed, registration.String())
+		}
+	}
+	expected := []string{
+		"ioctest_test.Broken",
+		"ioctest_test.Healthy named 'broken'",
+		"*ioctest_test.Broken in module 'db'",
+	}
+	if !slices.Equal(failed, expected) {
+		t.Errorf("expected %v to fail and got %v", expected, failed)
+	}
+}
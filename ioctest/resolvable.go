@@ -0,0 +1,49 @@
+package ioctest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+	"github.com/ogiusek/ioc/v2/internal/hooks"
+)
+
+// AssertEachResolvable creates fresh lazy container for every registered service
+// and resolves the service alone. Named services and private services are resolved too, the latter in their module.
+// Every service is a subtest named after the service so one broken creator doesn't hide others.
+// Failing subtests report resolution path of the service
+//
+// Example:
+//
+//	func TestWiring(t *testing.T) {
+//	    ioctest.AssertEachResolvable(t, app.Pkg)
+//	}
+func AssertEachResolvable(t *testing.T, pkgs ...ioc.Pkg) {
+	t.Helper()
+	c, err := ioc.TryNewContainer(lazy(pkgs)...)
+	if err != nil {
+		t.Fatalf("%s", report(err))
+	}
+	for _, registration := range c.Registrations() {
+		t.Run(registration.String(), func(t *testing.T) {
+			if err := resolve(pkgs, registration); err != nil {
+				t.Errorf("%s", report(err))
+			}
+		})
+	}
+}
+
+func lazy(pkgs []ioc.Pkg) []ioc.Pkg {
+	return append(slices.Clone(pkgs), ioc.LazyConstruction)
+}
+
+// resolve resolves registered service in a fresh lazy container and closes it
+func resolve(pkgs []ioc.Pkg, registration ioc.Registration) error {
+	c, err := ioc.TryNewContainer(lazy(pkgs)...)
+	if err != nil {
+		return err
+	}
+	_, err = hooks.ResolveRegistration(c, registration)
+	return errors.Join(err, c.Close())
+}
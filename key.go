@@ -0,0 +1,44 @@
+package ioc
+
+// Key is a handle of service returned by RegisterKey and ProvideKey.
+// Key.Get indexes registered services directly instead of looking service up in a map.
+// Key used with a container where service is registered in a different order falls back to Get
+type Key[Service any] struct {
+	index int
+	id    serviceID
+}
+
+// registers service with singleton lifetime and returns its key
+func RegisterKey[Service any](b Builder, creator func(c Dic) Service) Key[Service] {
+	return register[Service](b, newService(func(c Dic) any { return creator(c) }))
+}
+
+// registers already created service with singleton lifetime and returns its key
+func ProvideKey[Service any](b Builder, value Service) Key[Service] {
+	return register[Service](b, newService(func(c Dic) any { return value }))
+}
+
+// TryGet works like TryGet function
+func (k Key[Service]) TryGet(c Dic) (Service, error) {
+	if err := c.checkSealed(typeKey[Service]()); err != nil {
+		var s Service
+		return s, err
+	}
+	if k.index < len(c.c.keys) && k.id != nil && c.c.keys[k.index] == k.id {
+		service := &c.c.services[k.index]
+		if !service.private && service.created() {
+			instance, _ := (*service.instance).(Service)
+			return instance, nil
+		}
+	}
+	return tryGet[Service](c, typeKey[Service](), "")
+}
+
+// Get works like Get function
+func (k Key[Service]) Get(c Dic) Service {
+	s, err := k.TryGet(c)
+	if err != nil {
+		throw(err)
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package ioc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func TestKey(t *testing.T) {
+	type Config struct{ Name string }
+	type Other struct{}
+
+	var key ioc.Key[Config]
+	pkg := func(b ioc.Builder) {
+		key = ioc.ProvideKey(b, Config{Name: "config"})
+	}
+	c := ioc.NewContainer(pkg)
+	if name := key.Get(c).Name; name != "config" {
+		t.Errorf("expected service retrieved by key and got %v", name)
+	}
+	if name := ioc.Get[Config](c).Name; name != "config" {
+		t.Errorf("expected Get to keep working and got %v", name)
+	}
+
+	// key is used with a container where service is registered in a different order
+	reordered := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Other { return Other{} })
+		ioc.Provide(b, Config{Name: "reordered"})
+	})
+	if name := key.Get(reordered).Name; name != "reordered" {
+		t.Errorf("expected key to fall back to Get and got %v", name)
+	}
+
+	var zero ioc.Key[Other]
+	if _, err := zero.TryGet(c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected ErrServiceIsntRegistered and got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+// Module is a named package which can require other modules.
+// Modules are deduplicated by name so every module is registered once in a container
+// even when it is required by multiple modules. Modules with the same name have to be created
+// from the same register function with equal configs
+type Module struct {
+	name     string
+	config   any
+	tags     []string
+	profiles []string
+	requires []Module
+	register func(b Builder)
+	// registerID identifies register function passed by the user. It is compared like packages
+	registerID unsafe.Pointer
+}
+
+// ModuleOption configures module
+type ModuleOption func(m *Module)
+
+// Requires includes modules before the module. Required modules are included transitively
+func Requires(modules ...Module) ModuleOption {
+	return func(m *Module) { m.requires = append(m.requires, modules...) }
+}
+
+// Tags are used to select modules from the global registry
+func Tags(tags ...string) ModuleOption {
+	return func(m *Module) { m.tags = append(m.tags, tags...) }
+}
+
+func NewModule(name string, register func(b Builder), options ...ModuleOption) Module {
+	return newModule(name, nil, register, funcID(register), options)
+}
+
+// NewModuleT creates parametrized module.
+// Modules with the same name have to be created with equal configs.
+// Functions in configs are equal when they are the same function value
+func NewModuleT[Config any](name string, register func(Builder, Config), options ...ModuleOption) func(Config) Module {
+	registerID := funcID(register)
+	return func(c Config) Module {
+		return newModule(name, c, func(b Builder) { register(b, c) }, registerID, options)
+	}
+}
+
+func newModule(name string, config any, register func(b Builder), registerID unsafe.Pointer, options []ModuleOption) Module {
+	if name == "" {
+		throw(errors.Join(ErrInvalidModule, fmt.Errorf("module name cannot be empty")))
+	}
+	m := Module{name: name, config: config, register: register, registerID: registerID}
+	for _, option := range options {
+		option(&m)
+	}
+	return m
+}
+
+func (m Module) Name() string   { return m.name }
+func (m Module) Tags() []string { return slices.Clone(m.tags) }
+
+func (m Module) hasTag(tag string) bool { return slices.Contains(m.tags, tag) }
+
+// Pkg returns package including the module
+func (m Module) Pkg() Pkg {
+	return func(b Builder) { b.include(m) }
+}
+
+func (b Builder) include(m Module) {
+	if len(m.profiles) != 0 {
+		b.b.profiled = append(b.b.profiled, m)
+		return
+	}
+	b.includeNow(m)
+}
+
+func (b Builder) includeNow(m Module) {
+	if included, ok := b.b.modules[m.name]; ok {
+		if included.registerID != m.registerID {
+			throw(errors.Join(
+				ErrModuleConflict,
+				fmt.Errorf("module '%s' is included with different register functions", m.name),
+			))
+		}
+		if !sameConfig(reflect.ValueOf(included.config), reflect.ValueOf(m.config), map[configVisit]bool{}) {
+			throw(errors.Join(
+				ErrModuleConflict,
+				fmt.Errorf("module '%s' is included with different configs '%v' and '%v'", m.name, included.config, m.config),
+			))
+		}
+		return
+	}
+	if b.b.modules == nil {
+		b.b.modules = map[string]Module{}
+	}
+	b.b.modules[m.name] = m
+	for _, required := range m.requires {
+		b.include(required)
+	}
+	parent := b.b.module
+	b.b.module = m.name
+	defer func() { b.b.module = parent }()
+	m.register(b)
+}
+
+// configVisit is a pair of compared references. Like in reflect.DeepEqual pairs which are being compared are equal
+// so cyclic configs are compared in finite time
+type configVisit struct {
+	a, b unsafe.Pointer
+	typ  reflect.Type
+}
+
+// sameConfig works like reflect.DeepEqual but functions are equal when they are the same closure
+// so configs holding callbacks don't conflict with themselves
+func sameConfig(a, b reflect.Value, visited map[configVisit]bool) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		visit := configVisit{a: a.UnsafePointer(), b: b.UnsafePointer(), typ: a.Type()}
+		if visited[visit] {
+			return true
+		}
+		visited[visit] = true
+	}
+	switch a.Kind() {
+	case reflect.Func:
+		return closureID(a) == closureID(b)
+	case reflect.Pointer:
+		return a.Pointer() == b.Pointer() || sameConfig(a.Elem(), b.Elem(), visited)
+	case reflect.Interface:
+		return sameConfig(a.Elem(), b.Elem(), visited)
+	case reflect.Struct:
+		a, b = addressable(a), addressable(b)
+		for i := range a.NumField() {
+			if !sameConfig(readable(a.Field(i)), readable(b.Field(i)), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := range a.Len() {
+			if !sameConfig(a.Index(i), b.Index(i), visited) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, key := range a.MapKeys() {
+			if !sameConfig(a.MapIndex(key), b.MapIndex(key), visited) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Equal(b)
+	}
+}
+
+// closureID identifies function value like funcID
+func closureID(fn reflect.Value) unsafe.Pointer {
+	return *(*unsafe.Pointer)(addressable(fn).Addr().UnsafePointer())
+}
+
+// addressable returns v or its addressable copy
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	return copied
+}
+
+// readable returns addressable field which can be copied even when it is unexported
+func readable(field reflect.Value) reflect.Value {
+	return reflect.NewAt(field.Type(), field.Addr().UnsafePointer()).Elem()
+}
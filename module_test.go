@@ -0,0 +1,190 @@
+package ioc_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func TestModuleDeduplication(t *testing.T) {
+	type Config struct{ Val int }
+	type Logger struct{}
+	type Service struct{ Val int }
+
+	registrations := 0
+	logging := ioc.NewModule("logging", func(b ioc.Builder) {
+		registrations++
+		ioc.Register(b, func(c ioc.Dic) Logger { return Logger{} })
+	})
+	service := ioc.NewModuleT("service", func(b ioc.Builder, cfg Config) {
+		ioc.Register(b, func(c ioc.Dic) Service { return Service{Val: cfg.Val} })
+	}, ioc.Requires(logging))
+
+	c := ioc.NewContainer(
+		service(Config{Val: 7}).Pkg(),
+		service(Config{Val: 7}).Pkg(),
+		logging.Pkg(),
+	)
+
+	if registrations != 1 {
+		t.Errorf("expected required module to be registered once and it was registered %v times", registrations)
+	}
+	if ioc.Get[Service](c).Val != 7 {
+		t.Errorf("unexpected service %v", ioc.Get[Service](c))
+	}
+	ioc.Get[Logger](c)
+}
+
+func TestModuleConflict(t *testing.T) {
+	type Config struct{ Val int }
+	service := ioc.NewModuleT("service", func(b ioc.Builder, cfg Config) {})
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ioc.ErrModuleConflict) {
+			t.Errorf("expected ErrModuleConflict and got %v", err)
+		}
+	}()
+	ioc.NewContainer(service(Config{Val: 1}).Pkg(), service(Config{Val: 2}).Pkg())
+}
+
+func TestModuleConflictRegister(t *testing.T) {
+	first := ioc.NewModule("db", func(b ioc.Builder) { ioc.Provide(b, 1) })
+	second := ioc.NewModule("db", func(b ioc.Builder) { ioc.Provide(b, "text") })
+
+	if _, err := ioc.TryNewContainer(first.Pkg(), second.Pkg()); !errors.Is(err, ioc.ErrModuleConflict) {
+		t.Errorf("expected ErrModuleConflict and got %v", err)
+	}
+	if _, err := ioc.TryNewContainer(first.Pkg(), first.Pkg()); err != nil {
+		t.Errorf("expected the same module to be deduplicated and got %v", err)
+	}
+}
+
+func TestModuleConfigWithFunc(t *testing.T) {
+	type Config struct {
+		Name    string
+		OnError func(error)
+	}
+	onError := func(error) {}
+	service := ioc.NewModuleT("service", func(b ioc.Builder, cfg Config) {})
+
+	if _, err := ioc.TryNewContainer(service(Config{OnError: onError}).Pkg(), service(Config{OnError: onError}).Pkg()); err != nil {
+		t.Errorf("expected equal configs with functions not to conflict and got %v", err)
+	}
+	_, err := ioc.TryNewContainer(service(Config{Name: "a", OnError: onError}).Pkg(), service(Config{Name: "b", OnError: onError}).Pkg())
+	if !errors.Is(err, ioc.ErrModuleConflict) {
+		t.Errorf("expected ErrModuleConflict and got %v", err)
+	}
+}
+
+func TestModuleConfigClosures(t *testing.T) {
+	type Config struct{ onError func(error) }
+	var handled []string
+	handler := func(name string) func(error) { return func(error) { handled = append(handled, name) } }
+	service := ioc.NewModuleT("service", func(b ioc.Builder, cfg Config) {})
+
+	onError := handler("a")
+	if _, err := ioc.TryNewContainer(service(Config{onError: onError}).Pkg(), service(Config{onError: onError}).Pkg()); err != nil {
+		t.Errorf("expected the same closure not to conflict and got %v", err)
+	}
+	_, err := ioc.TryNewContainer(service(Config{onError: handler("a")}).Pkg(), service(Config{onError: handler("b")}).Pkg())
+	if !errors.Is(err, ioc.ErrModuleConflict) {
+		t.Errorf("expected closures capturing different state to conflict and got %v", err)
+	}
+}
+
+func TestModuleConfigCycles(t *testing.T) {
+	type Node struct {
+		Name string
+		Next *Node
+	}
+	cycle := func(name string) *Node {
+		node := &Node{Name: name}
+		node.Next = node
+		return node
+	}
+	service := ioc.NewModuleT("service", func(b ioc.Builder, cfg *Node) {})
+
+	if _, err := ioc.TryNewContainer(service(cycle("a")).Pkg(), service(cycle("a")).Pkg()); err != nil {
+		t.Errorf("expected equal cyclic configs not to conflict and got %v", err)
+	}
+	_, err := ioc.TryNewContainer(service(cycle("a")).Pkg(), service(cycle("b")).Pkg())
+	if !errors.Is(err, ioc.ErrModuleConflict) {
+		t.Errorf("expected ErrModuleConflict and got %v", err)
+	}
+}
+
+func TestPkgTDistinctConfigs(t *testing.T) {
+	type Config struct{ Name string }
+	pkg := ioc.NewPkgT(func(b ioc.Builder, cfg Config) {
+		ioc.RegisterNamed(b, cfg.Name, func(c ioc.Dic) Config { return cfg })
+	})
+
+	c := ioc.NewContainer(pkg(Config{Name: "a"}), pkg(Config{Name: "b"}))
+	if ioc.GetNamed[Config](c, "a").Name != "a" || ioc.GetNamed[Config](c, "b").Name != "b" {
+		t.Errorf("expected packages created by the same NewPkgT to be registered separately")
+	}
+}
+
+func TestPkgTSameConfig(t *testing.T) {
+	type Config struct{ Name string }
+	calls := 0
+	pkg := ioc.NewPkgT(func(b ioc.Builder, cfg Config) {
+		calls++
+		ioc.Provide(b, cfg)
+	})
+
+	if _, err := ioc.TryNewContainer(pkg(Config{Name: "a"}), pkg(Config{Name: "a"})); err != nil || calls != 1 {
+		t.Errorf("expected packages with equal configs to be registered once and got %v after %v calls", err, calls)
+	}
+}
+
+func TestPrivateServices(t *testing.T) {
+	type Conn struct{ Val int }
+	type Repo struct{ Conn Conn }
+	type Handler struct{ Err error }
+
+	wrapped := false
+	db := ioc.NewModule("db", func(b ioc.Builder) {
+		ioc.RegisterPrivate(b, func(c ioc.Dic) Conn { return Conn{Val: 7} })
+		ioc.Register(b, func(c ioc.Dic) Repo { return Repo{Conn: ioc.Get[Conn](c)} })
+		ioc.Wrap(b, func(c ioc.Dic, r Repo) { wrapped = ioc.Get[ioc.Lazy[Conn]](c)().Val == 7 })
+	})
+	api := ioc.NewModule("api", func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Handler {
+			_, err := ioc.TryGet[Conn](c)
+			return Handler{Err: err}
+		})
+	}, ioc.Requires(db))
+
+	c := ioc.NewContainer(api.Pkg())
+
+	if ioc.Get[Repo](c).Conn.Val != 7 || !wrapped {
+		t.Errorf("expected private service to be resolved inside of its module")
+	}
+	err := ioc.Get[Handler](c).Err
+	if !errors.Is(err, ioc.ErrPrivateService) || !strings.Contains(err.Error(), "'db'") || !strings.Contains(err.Error(), "'api'") {
+		t.Errorf("expected error naming both modules and got %v", err)
+	}
+	if _, err := ioc.TryGet[Conn](c); !errors.Is(err, ioc.ErrPrivateService) {
+		t.Errorf("expected ErrPrivateService outside of modules and got %v", err)
+	}
+	var conn Conn
+	if err := c.Inject(&conn); !errors.Is(err, ioc.ErrPrivateService) {
+		t.Errorf("expected ErrPrivateService outside of modules and got %v", err)
+	}
+}
+
+func TestPrivateServiceOutsideOfModule(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ioc.ErrInvalidServiceRegistration) {
+			t.Errorf("expected ErrInvalidServiceRegistration and got %v", err)
+		}
+	}()
+	ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterPrivate(b, func(c ioc.Dic) int { return 0 })
+	})
+}
@@ -0,0 +1,161 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Out is embedded in result structs. Fields of result structs with `inject` tag are registered
+// as singletons instead of the struct being registered as a service.
+// `inject:""` registers field under its type and `inject:"name=x"` registers it under name x.
+// All fields share one constructor call and are wrapped independently.
+// Result structs are supported by RegisterCtor
+//
+// Example:
+//
+//	type Database struct {
+//	    ioc.Out
+//	    Client   *db.Client   `inject:""`
+//	    Migrator *db.Migrator `inject:""`
+//	}
+//
+//	ioc.RegisterCtor(b, func(cfg Config) (Database, error) { ... })
+type Out struct{}
+
+var outType = reflect.TypeFor[Out]()
+
+// RegisterMulti registers both results of ctor with singleton lifetime.
+// Services share one ctor call and are wrapped independently. Returned error fails construction of both services
+//
+// Example:
+//
+//	ioc.RegisterMulti(b, func(c ioc.Dic) (*db.Client, *db.Migrator, error) { ... })
+func RegisterMulti[A, B any](b Builder, ctor func(c Dic) (A, B, error)) {
+	m := &multi{name: reflect.TypeOf(ctor).String(), call: newService(nil), ctor: func(c Dic) ([]any, error) {
+		a, b, err := ctor(c)
+		return []any{a, b}, err
+	}}
+	register[A](b, newService(func(c Dic) any { return m.output(c, 0) }))
+	register[B](b, newService(func(c Dic) any { return m.output(c, 1) }))
+}
+
+// multi is a ctor call shared by services it produces
+type multi struct {
+	// name of the ctor used in errors
+	name string
+	ctor func(c Dic) ([]any, error)
+	// call is locked like a service so services produced by the ctor can be created concurrently
+	call service
+	out  []any
+}
+
+// output calls ctor when it isn't called yet and returns its i-th result.
+// Failure is cached like failure of a service unless RetryFailedConstruction is used
+func (m *multi) output(c Dic, i int) any {
+	if out, ok := m.called(); ok {
+		return out[i]
+	}
+	c, done := c.resolving()
+	if done != nil {
+		defer done()
+	}
+	if ok := c.lockService(m.call, c.resolution); !ok {
+		throw(errors.Join(
+			ErrCircularDependency,
+			fmt.Errorf("ctor '%s' requests its own result", m.name),
+		))
+	}
+	defer c.unlockService(m.call, nil)
+	if out, ok := m.called(); ok {
+		return out[i]
+	}
+	out, err := m.ctor(c)
+	if err != nil {
+		if !c.c.retryFailed {
+			*m.call.err = err
+			m.call.state.Store(serviceFailed)
+		}
+		panic(err)
+	}
+	m.out = out
+	m.call.state.Store(serviceCreated)
+	return out[i]
+}
+
+// called returns results of the ctor when it is called. Cached failure is panicked
+func (m *multi) called() ([]any, bool) {
+	switch m.call.state.Load() {
+	case serviceCreated:
+		return m.out, true
+	case serviceFailed:
+		panic(*m.call.err)
+	}
+	return nil, false
+}
+
+// isOut returns true when t is a result struct embedding Out
+func isOut(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range t.NumField() {
+		if field := t.Field(i); field.Anonymous && field.Type == outType {
+			return true
+		}
+	}
+	return false
+}
+
+// registerOut registers tagged fields of the result struct returned by call with singleton lifetime
+func (b Builder) registerOut(name string, resultType reflect.Type, call func(c Dic) (reflect.Value, error)) {
+	var fields []reflect.StructField
+	var keys []serviceID
+	for i := range resultType.NumField() {
+		field := resultType.Field(i)
+		tagValue, ok := field.Tag.Lookup("inject")
+		if !ok {
+			continue
+		}
+		tag, err := parseInjectTag(tagValue)
+		if err == nil && (tag.embed || tag.optional) {
+			err = errors.Join(ErrInvalidInjectTag, fmt.Errorf("embed and optional options cannot be used in result struct"))
+		}
+		if err == nil && !field.IsExported() {
+			err = fmt.Errorf("unexported field cannot be registered")
+		}
+		if err != nil {
+			throw(errors.Join(
+				ErrInvalidServiceRegistration,
+				fmt.Errorf("field '%s' of result struct '%s': %w", field.Name, resultType.String(), err),
+			))
+		}
+		key := serviceKey(field.Type)
+		if tag.name != "" {
+			key = namedKey(key, tag.name)
+		}
+		fields = append(fields, field)
+		keys = append(keys, key)
+	}
+	if len(fields) == 0 {
+		throw(errors.Join(
+			ErrInvalidServiceRegistration,
+			fmt.Errorf("result struct '%s' has no fields with inject tag", resultType.String()),
+		))
+	}
+
+	m := &multi{name: name, call: newService(nil), ctor: func(c Dic) ([]any, error) {
+		result, err := call(c)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]any, len(fields))
+		for i, field := range fields {
+			out[i] = result.FieldByIndex(field.Index).Interface()
+		}
+		return out, nil
+	}}
+	for i := range fields {
+		b.register(keys[i], newService(func(c Dic) any { return m.output(c, i) }))
+	}
+}
@@ -0,0 +1,151 @@
+package ioc_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+type multiClient struct{ Wrapped bool }
+type multiMigrator struct{ Client *multiClient }
+
+func TestRegisterMulti(t *testing.T) {
+	calls := 0
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterMulti(b, func(c ioc.Dic) (*multiClient, *multiMigrator, error) {
+			calls++
+			client := &multiClient{}
+			return client, &multiMigrator{Client: client}, nil
+		})
+		ioc.Wrap(b, func(c ioc.Dic, s *multiClient) { s.Wrapped = true })
+	})
+
+	client := ioc.Get[*multiClient](c)
+	migrator := ioc.Get[*multiMigrator](c)
+	if calls != 1 {
+		t.Errorf("expected ctor to be called once and it was called %v times", calls)
+	}
+	if migrator.Client != client || !client.Wrapped {
+		t.Errorf("unexpected services %v %v", client, migrator)
+	}
+}
+
+func TestRegisterMultiParallel(t *testing.T) {
+	var calls atomic.Int32
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.RegisterMulti(b, func(c ioc.Dic) (*multiClient, *multiMigrator, error) {
+			calls.Add(1)
+			time.Sleep(time.Millisecond)
+			client := &multiClient{}
+			return client, &multiMigrator{Client: client}, nil
+		})
+	})
+
+	var wg sync.WaitGroup
+	var client *multiClient
+	var migrator *multiMigrator
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		client = ioc.Get[*multiClient](c)
+	}()
+	go func() {
+		defer wg.Done()
+		migrator = ioc.Get[*multiMigrator](c)
+	}()
+	wg.Wait()
+	if calls := calls.Load(); calls != 1 {
+		t.Errorf("expected ctor to be called once and it was called %v times", calls)
+	}
+	if migrator.Client != client {
+		t.Errorf("expected services of one ctor call and got %v %v", client, migrator)
+	}
+}
+
+func TestRegisterMultiError(t *testing.T) {
+	errMulti := errors.New("multi")
+	calls := 0
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.RegisterMulti(b, func(c ioc.Dic) (*multiClient, *multiMigrator, error) {
+			calls++
+			return nil, nil, errMulti
+		})
+	})
+
+	if _, err := ioc.TryGet[*multiClient](c); !errors.Is(err, ioc.ErrConstructionFailed) || !errors.Is(err, errMulti) {
+		t.Errorf("expected ctor error and got %v", err)
+	}
+	if _, err := ioc.TryGet[*multiMigrator](c); !errors.Is(err, errMulti) {
+		t.Errorf("expected ctor error and got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected failure to be cached and ctor was called %v times", calls)
+	}
+}
+
+func TestRegisterMultiCircular(t *testing.T) {
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.RegisterMulti(b, func(c ioc.Dic) (*multiClient, *multiMigrator, error) {
+			return ioc.Get[*multiMigrator](c).Client, &multiMigrator{}, nil
+		})
+	})
+
+	if _, err := ioc.TryGet[*multiClient](c); !errors.Is(err, ioc.ErrCircularDependency) {
+		t.Errorf("expected ErrCircularDependency and got %v", err)
+	}
+}
+
+type multiOut struct {
+	ioc.Out
+	Client   *multiClient   `inject:""`
+	Migrator *multiMigrator `inject:"name=primary"`
+	Ignored  int
+}
+
+func TestRegisterCtorOut(t *testing.T) {
+	calls := 0
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterCtor(b, func() (multiOut, error) {
+			calls++
+			client := &multiClient{}
+			return multiOut{Client: client, Migrator: &multiMigrator{Client: client}}, nil
+		})
+		ioc.Wrap(b, func(c ioc.Dic, s *multiClient) { s.Wrapped = true })
+	})
+
+	client := ioc.Get[*multiClient](c)
+	migrator := ioc.GetNamed[*multiMigrator](c, "primary")
+	if calls != 1 {
+		t.Errorf("expected ctor to be called once and it was called %v times", calls)
+	}
+	if migrator.Client != client || !client.Wrapped {
+		t.Errorf("unexpected services %v %v", client, migrator)
+	}
+	if _, err := ioc.TryGet[multiOut](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected result struct not to be registered and got %v", err)
+	}
+	if _, err := ioc.TryGet[int](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected untagged field not to be registered and got %v", err)
+	}
+}
+
+func TestRegisterCtorInvalidOut(t *testing.T) {
+	type Invalid struct {
+		ioc.Out
+		Client *multiClient `inject:",optional"`
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ioc.ErrInvalidServiceRegistration) || !errors.Is(err, ioc.ErrInvalidInjectTag) {
+			t.Errorf("expected ErrInvalidServiceRegistration and got %v", err)
+		}
+	}()
+	ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterCtor(b, func() Invalid { return Invalid{} })
+	})
+}
@@ -0,0 +1,201 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// injectPlan is a compiled injection of a struct type. Plans are cached by container
+type injectPlan struct {
+	fields []plannedField
+	// direct is set when every field is an already created service so plan can be injected without allocations
+	direct bool
+}
+
+type plannedField struct {
+	offset uintptr
+	typ    reflect.Type
+	path   string
+	// err is returned every time field is injected, e.g. when its tag is invalid
+	err error
+
+	key  serviceID
+	name string
+	// index of the registered service or -1 when service isn't registered
+	index    int
+	optional bool
+	// created is [1]T array holding service which was created when plan was compiled.
+	// Arrays are copied with reflect.Copy which doesn't let destination escape
+	created reflect.Value
+
+	// embed is a plan of embedded struct
+	embed *injectPlan
+	// embedPointer is set when embedded field is a pointer to a struct
+	embedPointer bool
+}
+
+// plan returns cached plan of the struct type
+func (c Dic) plan(t reflect.Type) *injectPlan {
+	if plan, ok := c.c.plans.Load(t); ok {
+		return plan.(*injectPlan)
+	}
+	plan, _ := c.c.plans.LoadOrStore(t, c.compile(t, t.String(), map[reflect.Type]bool{}))
+	return plan.(*injectPlan)
+}
+
+// compile creates plan of the struct type. embedding are struct types being compiled
+func (c Dic) compile(t reflect.Type, path string, embedding map[reflect.Type]bool) *injectPlan {
+	embedding[t] = true
+	defer delete(embedding, t)
+
+	plan := &injectPlan{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tagValue, ok := field.Tag.Lookup("inject")
+		if !ok {
+			continue
+		}
+		f := plannedField{offset: field.Offset, typ: field.Type, path: path + "." + field.Name, index: -1}
+
+		tag, err := parseInjectTag(tagValue)
+		switch {
+		case err != nil:
+			f.err = fmt.Errorf("field '%s': %w", f.path, err)
+		case !field.IsExported() && !c.c.injectUnexported:
+			f.err = fmt.Errorf("field '%s': %w", f.path, ErrUnexportedField)
+		case tag.embed:
+			c.compileEmbedded(&f, embedding)
+		default:
+			f.name = tag.name
+			f.optional = tag.optional
+			f.key = namedKey(serviceKey(field.Type), tag.name)
+			if i, ok := c.c.index[f.key]; ok {
+				service := c.c.services[i]
+				f.index = i
+				if service.created() {
+					f.created = reflect.New(reflect.ArrayOf(1, field.Type)).Elem()
+					if instance := *service.instance; instance != nil {
+						f.created.Index(0).Set(reflect.ValueOf(instance))
+					}
+				}
+			}
+		}
+		plan.fields = append(plan.fields, f)
+	}
+	plan.direct = true
+	for _, f := range plan.fields {
+		if !f.created.IsValid() {
+			plan.direct = false
+		}
+	}
+	return plan
+}
+
+func (c Dic) compileEmbedded(f *plannedField, embedding map[reflect.Type]bool) {
+	t := f.typ
+	switch {
+	case t.Kind() == reflect.Struct:
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct:
+		f.embedPointer = true
+		t = t.Elem()
+	default:
+		f.err = fmt.Errorf("field '%s': %w", f.path, errors.Join(
+			ErrIsntPointerToStruct,
+			fmt.Errorf("embed option expects struct or pointer to struct, got %s", t.String()),
+		))
+		return
+	}
+	if embedding[t] {
+		f.err = fmt.Errorf("field '%s': %w", f.path, errors.Join(
+			ErrInvalidInjectTag,
+			fmt.Errorf("struct '%s' is embedded in itself", t.String()),
+		))
+		return
+	}
+	f.embed = c.compile(t, f.path, embedding)
+}
+
+// inject injects services into struct at the pointer. Returns error for every field which couldn't be injected
+func (c Dic) inject(plan *injectPlan, ptr unsafe.Pointer, errs []error) []error {
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		if f.err != nil {
+			errs = append(errs, f.err)
+			continue
+		}
+		fieldPtr := unsafe.Add(ptr, f.offset)
+		if f.embed != nil {
+			if f.embedPointer {
+				embedded := (*unsafe.Pointer)(fieldPtr)
+				if *embedded == nil {
+					*embedded = reflect.New(f.typ.Elem()).UnsafePointer()
+				}
+				fieldPtr = *embedded
+			}
+			errs = c.inject(f.embed, fieldPtr, errs)
+			continue
+		}
+
+		fieldValue := reflect.NewAt(f.typ, fieldPtr).Elem()
+		if f.index == -1 {
+			// unregistered services can still be injected by adapters and resolvers
+			ok, err := c.find(fieldValue.Addr().Interface(), f.name)
+			switch {
+			case err != nil:
+				errs = append(errs, fmt.Errorf("field '%s': %w", f.path, err))
+			case !ok && f.optional:
+				fieldValue.SetZero()
+			case !ok:
+				errs = append(errs, fmt.Errorf("field '%s': %w", f.path, missing(f.key, f.name)))
+			}
+			continue
+		}
+
+		if err := c.injectService(f, fieldValue); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// injectDirect injects plan which has only created services.
+// ptr doesn't escape so structs on the stack can be injected
+func (c Dic) injectDirect(plan *injectPlan, ptr unsafe.Pointer) []error {
+	var errs []error
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		if service := c.c.services[f.index]; service.private {
+			if err := c.visible(f.key, service); err != nil {
+				errs = append(errs, fmt.Errorf("field '%s': %w", f.path, err))
+				continue
+			}
+		}
+		reflect.Copy(reflect.NewAt(f.created.Type(), unsafe.Add(ptr, f.offset)).Elem(), f.created)
+	}
+	return errs
+}
+
+// injectService sets field to the registered service
+func (c Dic) injectService(f *plannedField, fieldValue reflect.Value) error {
+	service := c.c.services[f.index]
+	var instance any
+	if service.created() && !service.private {
+		instance = *service.instance
+	} else {
+		var err error
+		if err = c.visible(f.key, service); err == nil {
+			instance, err = c.create(f.key, service)
+		}
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", f.path, err)
+		}
+	}
+	if instance == nil {
+		fieldValue.SetZero()
+		return nil
+	}
+	fieldValue.Set(reflect.ValueOf(instance))
+	return nil
+}
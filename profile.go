@@ -0,0 +1,32 @@
+package ioc
+
+import "slices"
+
+// WithProfile is an option which activates profiles.
+// Modules created with OnlyIn option are included only when one of their profiles is active
+func WithProfile(profiles ...string) Pkg {
+	return func(b Builder) { b.b.profiles = append(b.b.profiles, profiles...) }
+}
+
+// OnlyIn includes module only when one of the profiles is active.
+// Profiles are checked after all packages are registered so option order doesn't matter
+func OnlyIn(profiles ...string) ModuleOption {
+	return func(m *Module) { m.profiles = append(m.profiles, profiles...) }
+}
+
+func (b Builder) profileActive(profiles []string) bool {
+	return slices.ContainsFunc(profiles, func(profile string) bool { return slices.Contains(b.b.profiles, profile) })
+}
+
+// includeProfiled includes modules created with OnlyIn option which profiles are active
+func (b Builder) includeProfiled() {
+	for len(b.b.profiled) != 0 {
+		modules := b.b.profiled
+		b.b.profiled = nil
+		for _, m := range modules {
+			if b.profileActive(m.profiles) {
+				b.includeNow(m)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package ioc_test
+
+import (
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func TestProfiles(t *testing.T) {
+	type Mailer struct{ Name string }
+	type App struct{ Mailer Mailer }
+
+	smtp := ioc.NewModule("smtp", func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Mailer { return Mailer{Name: "smtp"} })
+	}, ioc.OnlyIn("prod", "dev"))
+	fake := ioc.NewModule("fake-mailer", func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Mailer { return Mailer{Name: "fake"} })
+	}, ioc.OnlyIn("test"))
+	app := ioc.NewModule("app", func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) App { return App{Mailer: ioc.Get[Mailer](c)} })
+	}, ioc.Requires(smtp, fake))
+
+	for profile, expected := range map[string]string{"prod": "smtp", "dev": "smtp", "test": "fake"} {
+		// profile option is passed after packages on purpose
+		c := ioc.NewContainer(app.Pkg(), ioc.WithProfile(profile))
+		if name := ioc.Get[App](c).Mailer.Name; name != expected {
+			t.Errorf("expected %v mailer in %v profile and got %v", expected, profile, name)
+		}
+	}
+}
+
+func TestRegisterIf(t *testing.T) {
+	type Enabled struct{}
+	type Disabled struct{}
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.RegisterIf(b, true, func(c ioc.Dic) Enabled { return Enabled{} })
+		ioc.RegisterIf(b, false, func(c ioc.Dic) Disabled { return Disabled{} })
+	})
+
+	if _, err := ioc.TryGet[Enabled](c); err != nil {
+		t.Errorf("expected service to be registered and got %v", err)
+	}
+	if _, err := ioc.TryGet[Disabled](c); err == nil {
+		t.Errorf("expected service not to be registered")
+	}
+}
@@ -0,0 +1,81 @@
+package ioc
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// Registry is a list of packages included by NewContainerFromRegistry.
+//
+// Deprecated: packages have no names so they cannot be filtered. Use AutoRegister with modules instead.
+// Packages from Registry are always included by NewContainerFromRegistry
+var Registry []Pkg
+
+var (
+	registryMutex sync.Mutex
+	registry      []Module
+)
+
+// AutoRegister adds module to the global registry.
+// It is meant to be called in `init()` so importing a package for side effects wires it in:
+//
+//	func init() { ioc.AutoRegister(Module) }
+func AutoRegister(modules ...Module) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry = append(registry, modules...)
+}
+
+// RegistryFilter selects modules from the global registry
+type RegistryFilter func(m Module) bool
+
+// SelectNames selects only modules with one of the names
+func SelectNames(names ...string) RegistryFilter {
+	return func(m Module) bool { return slices.Contains(names, m.name) }
+}
+
+// SelectTags selects only modules with at least one of the tags
+func SelectTags(tags ...string) RegistryFilter {
+	return func(m Module) bool { return slices.ContainsFunc(tags, m.hasTag) }
+}
+
+// ExcludeNames excludes modules with one of the names
+func ExcludeNames(names ...string) RegistryFilter {
+	return func(m Module) bool { return !slices.Contains(names, m.name) }
+}
+
+// ExcludeTags excludes modules with at least one of the tags
+func ExcludeTags(tags ...string) RegistryFilter {
+	return func(m Module) bool { return !slices.ContainsFunc(tags, m.hasTag) }
+}
+
+// RegistryPkg returns package including modules from the global registry which pass all filters.
+// Modules are included in name order so import order doesn't matter.
+// Modules required by selected modules are always included
+func RegistryPkg(filters ...RegistryFilter) Pkg {
+	registryMutex.Lock()
+	modules := slices.Clone(registry)
+	registryMutex.Unlock()
+
+	modules = slices.DeleteFunc(modules, func(m Module) bool {
+		return slices.ContainsFunc(filters, func(filter RegistryFilter) bool { return !filter(m) })
+	})
+	slices.SortStableFunc(modules, func(a, b Module) int { return cmp.Compare(a.name, b.name) })
+
+	legacy := slices.Clone(Registry)
+	return func(b Builder) {
+		for _, m := range modules {
+			b.include(m)
+		}
+		for _, pkg := range legacy {
+			pkg(b)
+		}
+	}
+}
+
+// NewContainerFromRegistry creates container from modules in the global registry which pass all filters.
+// To pass options use NewContainer with RegistryPkg
+func NewContainerFromRegistry(filters ...RegistryFilter) Dic {
+	return NewContainer(RegistryPkg(filters...))
+}
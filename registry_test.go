@@ -0,0 +1,49 @@
+package ioc_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+var registryOrder []string
+
+func newRegistryTestModule(name string, tags ...string) ioc.Module {
+	return ioc.NewModule(name, func(b ioc.Builder) {
+		registryOrder = append(registryOrder, name)
+	}, ioc.Tags(append(tags, "registry-test")...))
+}
+
+func init() {
+	ioc.AutoRegister(
+		newRegistryTestModule("registry-test-c"),
+		newRegistryTestModule("registry-test-a", "excluded"),
+		newRegistryTestModule("registry-test-b"),
+		newRegistryTestModule("registry-test-d"),
+	)
+}
+
+func TestNewContainerFromRegistry(t *testing.T) {
+	registryOrder = nil
+	ioc.NewContainerFromRegistry(ioc.SelectTags("registry-test"))
+	if expected := []string{"registry-test-a", "registry-test-b", "registry-test-c", "registry-test-d"}; !slices.Equal(registryOrder, expected) {
+		t.Errorf("expected modules %v and got %v", expected, registryOrder)
+	}
+
+	registryOrder = nil
+	ioc.NewContainerFromRegistry(
+		ioc.SelectTags("registry-test"),
+		ioc.ExcludeTags("excluded"),
+		ioc.ExcludeNames("registry-test-d"),
+	)
+	if expected := []string{"registry-test-b", "registry-test-c"}; !slices.Equal(registryOrder, expected) {
+		t.Errorf("expected modules %v and got %v", expected, registryOrder)
+	}
+
+	registryOrder = nil
+	ioc.NewContainer(ioc.RegistryPkg(ioc.SelectNames("registry-test-d")))
+	if expected := []string{"registry-test-d"}; !slices.Equal(registryOrder, expected) {
+		t.Errorf("expected modules %v and got %v", expected, registryOrder)
+	}
+}
@@ -0,0 +1,115 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Resolver is consulted by the container when a type has no registration.
+// Resolve returns false when it cannot resolve the type.
+// Resolved services are cached so every resolver is called at most once per type
+type Resolver interface {
+	Resolve(c Dic, t reflect.Type) (any, bool, error)
+}
+
+// ResolverFunc is a function implementing Resolver
+type ResolverFunc func(c Dic, t reflect.Type) (any, bool, error)
+
+func (f ResolverFunc) Resolve(c Dic, t reflect.Type) (any, bool, error) { return f(c, t) }
+
+// WithResolver is an option adding resolver consulted for types without registration.
+// Resolvers are consulted in addition order
+func WithResolver(r Resolver) Pkg {
+	return func(b Builder) { b.b.resolvers = append(b.b.resolvers, r) }
+}
+
+// resolve returns service resolved by resolvers. Returns false when no resolver resolved it.
+// Resolutions of the same type wait for each other like creations of registered services
+func (c Dic) resolve(key serviceID) (any, bool, error) {
+	if len(c.c.resolvers) == 0 {
+		return nil, false, nil
+	}
+	c.c.resolvedMutex.Lock()
+	resolved, ok := c.c.resolved[key]
+	if !ok {
+		resolved = newService(nil)
+		c.c.resolved[key] = resolved
+	}
+	c.c.resolvedMutex.Unlock()
+	if resolved.created() {
+		return *resolved.instance, true, nil
+	}
+
+	t := keyType(key)
+	c, done := c.resolving()
+	if done != nil {
+		defer done()
+	}
+	if ok := c.lockService(resolved, c.resolution); !ok {
+		return nil, false, errors.Join(
+			ErrCircularDependency,
+			fmt.Errorf("service of type '%s' is requested while being resolved", t.String()),
+		)
+	}
+	defer c.unlockService(resolved, nil)
+	if resolved.created() {
+		return *resolved.instance, true, nil
+	}
+	// retrievals of resolvers aren't reported by sealed container like retrievals of creators
+	var resolving atomic.Bool
+	resolving.Store(true)
+	defer resolving.Store(false)
+	c = c.during(&resolving)
+	for _, r := range c.c.resolvers {
+		instance, ok, err := r.Resolve(c, t)
+		if err != nil {
+			return nil, false, fmt.Errorf("resolving service of type '%s': %w", t.String(), err)
+		}
+		if !ok {
+			continue
+		}
+		*resolved.instance = instance
+		resolved.state.Store(serviceCreated)
+		return instance, true, nil
+	}
+	return nil, false, nil
+}
+
+// StructResolver constructs unregistered structs and pointers to structs
+// which have at least one field with `inject` tag, the same way as GetServices does.
+// Resolved structs are singletons like other resolved services
+//
+// Example:
+//
+//	c := ioc.NewContainer(Pkg, ioc.WithResolver(ioc.StructResolver()))
+//	handler := ioc.Get[*Handler](c) // Handler isn't registered
+func StructResolver() Resolver {
+	return ResolverFunc(func(c Dic, t reflect.Type) (any, bool, error) {
+		structType := t
+		if t.Kind() == reflect.Pointer {
+			structType = t.Elem()
+		}
+		if structType.Kind() != reflect.Struct || !hasInjectTag(structType) {
+			return nil, false, nil
+		}
+		value := reflect.New(structType)
+		if err := errors.Join(c.inject(c.plan(structType), value.UnsafePointer(), nil)...); err != nil {
+			return nil, false, err
+		}
+		if t.Kind() == reflect.Pointer {
+			return value.Interface(), true, nil
+		}
+		return value.Elem().Interface(), true, nil
+	})
+}
+
+func hasInjectTag(t reflect.Type) bool {
+	for i := range t.NumField() {
+		if _, ok := t.Field(i).Tag.Lookup("inject"); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package ioc_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func TestResolver(t *testing.T) {
+	type Config struct{ Name string }
+	type Missing struct{}
+	type Services struct {
+		Config Config `inject:""`
+	}
+
+	calls := 0
+	c := ioc.NewContainer(ioc.WithResolver(ioc.ResolverFunc(func(c ioc.Dic, t reflect.Type) (any, bool, error) {
+		if t != reflect.TypeFor[Config]() {
+			return nil, false, nil
+		}
+		calls++
+		return Config{Name: "resolved"}, true, nil
+	})))
+
+	if name := ioc.Get[Config](c).Name; name != "resolved" {
+		t.Errorf("expected resolved service and got %v", name)
+	}
+	if name := ioc.GetServices[Services](c).Config.Name; name != "resolved" {
+		t.Errorf("expected resolved service to be injected and got %v", name)
+	}
+	if calls != 1 {
+		t.Errorf("expected resolved service to be cached and resolver was called %v times", calls)
+	}
+	if _, err := ioc.TryGet[Missing](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected ErrServiceIsntRegistered and got %v", err)
+	}
+}
+
+func TestResolverCircularDependency(t *testing.T) {
+	type Config struct{}
+
+	c := ioc.NewContainer(ioc.WithResolver(ioc.ResolverFunc(func(c ioc.Dic, t reflect.Type) (any, bool, error) {
+		_, err := ioc.TryGet[Config](c)
+		return Config{}, true, err
+	})))
+	if _, err := ioc.TryGet[Config](c); !errors.Is(err, ioc.ErrCircularDependency) {
+		t.Errorf("expected ErrCircularDependency and got %v", err)
+	}
+}
+
+func TestStructResolver(t *testing.T) {
+	type Repo struct{ Name string }
+	type Handler struct {
+		Repo Repo `inject:""`
+	}
+	type Plain struct{ Name string }
+	type Broken struct {
+		Missing Plain `inject:""`
+	}
+
+	c := ioc.NewContainer(ioc.WithResolver(ioc.StructResolver()), func(b ioc.Builder) {
+		ioc.Provide(b, Repo{Name: "repo"})
+	})
+	if name := ioc.Get[Handler](c).Repo.Name; name != "repo" {
+		t.Errorf("expected struct to be constructed and got %v", name)
+	}
+	if handler := ioc.Get[*Handler](c); handler != ioc.Get[*Handler](c) || handler.Repo.Name != "repo" {
+		t.Errorf("expected pointer to struct to be constructed once")
+	}
+	if _, err := ioc.TryGet[Plain](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected struct without inject tags not to be resolved and got %v", err)
+	}
+	if _, err := ioc.TryGet[Broken](c); !errors.Is(err, ioc.ErrServiceIsntRegistered) {
+		t.Errorf("expected error of the field which isn't injectable and got %v", err)
+	}
+}
+
+func TestEnvResolver(t *testing.T) {
+	type Config struct {
+		Addr    string        `env:"ADDR,required"`
+		Port    int           `env:"PORT"`
+		Debug   bool          `env:"DEBUG"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		Unset   string        `env:"UNSET"`
+	}
+	type Invalid struct {
+		Port int `env:"ADDR"`
+	}
+	type Required struct {
+		Missing string `env:"MISSING,required"`
+	}
+	t.Setenv("APP_ADDR", "localhost")
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("APP_DEBUG", "true")
+	t.Setenv("APP_TIMEOUT", "2s")
+
+	c := ioc.NewContainer(ioc.WithResolver(ioc.EnvResolver("APP_")))
+	config := ioc.Get[*Config](c)
+	expected := Config{Addr: "localhost", Port: 8080, Debug: true, Timeout: 2 * time.Second}
+	if *config != expected {
+		t.Errorf("expected %v config and got %v", expected, *config)
+	}
+	if _, err := ioc.TryGet[Invalid](c); !errors.Is(err, ioc.ErrInvalidEnv) {
+		t.Errorf("expected ErrInvalidEnv for invalid number and got %v", err)
+	}
+	if _, err := ioc.TryGet[Required](c); !errors.Is(err, ioc.ErrInvalidEnv) {
+		t.Errorf("expected ErrInvalidEnv for missing required variable and got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// SealPolicy decides what happens when service is retrieved from a sealed container
+type SealPolicy uint8
+
+const (
+	// SealReturnError makes retrieval return ErrSealed. Get and GetServices panic with it
+	SealReturnError SealPolicy = iota
+	// SealPanic makes every retrieval panic with ErrSealed
+	SealPanic
+	// SealLog logs retrieval with a stack trace and retrieves service
+	SealLog
+)
+
+// SealViolation is a call site which retrieved service from a sealed container
+type SealViolation struct {
+	Service reflect.Type
+	// Caller is file:line of the retrieval
+	Caller string
+	Count  int
+}
+
+// WithSealPolicy is an option setting policy of sealed container. Default is SealReturnError
+func WithSealPolicy(policy SealPolicy) Pkg {
+	return func(b Builder) { b.b.sealPolicy = policy }
+}
+
+// SealAfterBuild is an option which seals container when NewContainer creates all services
+func SealAfterBuild(b Builder) {
+	b.b.seal = true
+}
+
+// Seal marks container as wired. Get, Inject and GetServices called afterwards are reported
+// according to the seal policy. Services retrieved by creators while they are called, wraps, factories, transients
+// and lazy getters aren't reported
+func (c Dic) Seal() {
+	c.c.sealed.Store(true)
+}
+
+// SealViolations returns every call site which retrieved service after container was sealed
+func (c Dic) SealViolations() []SealViolation {
+	c.c.violationsMutex.Lock()
+	defer c.c.violationsMutex.Unlock()
+	res := make([]SealViolation, len(c.c.violations))
+	for i, violation := range c.c.violations {
+		res[i] = *violation
+	}
+	return res
+}
+
+// checkSealed reports retrieval of the service when container is sealed.
+// key is a service type or a pointer to the service so pointer type is computed only when retrieval is reported
+func (c Dic) checkSealed(key any) error {
+	if !c.c.sealed.Load() || (c.creating != nil && c.creating.Load()) {
+		return nil
+	}
+	service, ok := key.(reflect.Type)
+	if !ok {
+		service = reflect.TypeOf(key).Elem()
+	}
+	return c.reportSealed(service)
+}
+
+// reportSealed records violation and applies seal policy
+func (c Dic) reportSealed(service reflect.Type) error {
+	caller := caller()
+	c.c.violationsMutex.Lock()
+	i := slices.IndexFunc(c.c.violations, func(v *SealViolation) bool {
+		return v.Service == service && v.Caller == caller
+	})
+	if i == -1 {
+		c.c.violations = append(c.c.violations, &SealViolation{Service: service, Caller: caller})
+		i = len(c.c.violations) - 1
+	}
+	c.c.violations[i].Count++
+	c.c.violationsMutex.Unlock()
+
+	err := errors.Join(
+		ErrSealed,
+		fmt.Errorf("service of type '%s' is retrieved at %s after container was sealed", service.String(), caller),
+	)
+	switch c.c.sealPolicy {
+	case SealPanic:
+		throw(err)
+	case SealLog:
+		log.Printf("ioc: %v\n%s", err, debug.Stack())
+		return nil
+	}
+	return err
+}
+
+// caller returns file:line of the first frame outside of the package
+func caller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/ogiusek/ioc/v2.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package ioc_test
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ogiusek/ioc/v2"
+)
+
+func TestSeal(t *testing.T) {
+	type Repo struct{}
+	type Handler struct {
+		c    ioc.Dic
+		Repo ioc.Lazy[Repo]
+	}
+	type Services struct {
+		Repo Repo `inject:""`
+	}
+
+	pkg := func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Repo { return Repo{} })
+		ioc.Register(b, func(c ioc.Dic) Handler { return Handler{c: c, Repo: ioc.Get[ioc.Lazy[Repo]](c)} })
+	}
+	c := ioc.NewContainer(pkg)
+	handler := ioc.Get[Handler](c)
+	c.Seal()
+
+	handler.Repo()
+	if violations := c.SealViolations(); len(violations) != 0 {
+		t.Errorf("expected lazy getter not to be reported and got %v", violations)
+	}
+
+	for range 2 {
+		if _, err := ioc.TryGet[Repo](handler.c); !errors.Is(err, ioc.ErrSealed) {
+			t.Errorf("expected ErrSealed and got %v", err)
+		}
+	}
+	if _, err := ioc.TryGetServices[Services](c); !errors.Is(err, ioc.ErrSealed) {
+		t.Errorf("expected ErrSealed from GetServices and got %v", err)
+	}
+	var repo Repo
+	if err := c.Inject(&repo); !errors.Is(err, ioc.ErrSealed) {
+		t.Errorf("expected ErrSealed from Inject and got %v", err)
+	}
+
+	violations := c.SealViolations()
+	if len(violations) != 3 {
+		t.Fatalf("expected every call site to be reported once and got %v", violations)
+	}
+	if violations[0].Count != 2 || !strings.Contains(violations[0].Caller, "seal_test.go:") {
+		t.Errorf("expected call site reported twice and got %v", violations[0])
+	}
+}
+
+func TestSealPolicy(t *testing.T) {
+	type Repo struct{}
+	pkg := func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Repo { return Repo{} })
+	}
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	c := ioc.NewContainer(pkg, ioc.WithSealPolicy(ioc.SealLog))
+	c.Seal()
+	if _, err := ioc.TryGet[Repo](c); err != nil {
+		t.Errorf("expected retrieval to be only logged and got %v", err)
+	}
+	if !strings.Contains(logs.String(), "goroutine") {
+		t.Errorf("expected retrieval to be logged with a stack trace and got %v", logs.String())
+	}
+
+	c = ioc.NewContainer(pkg, ioc.WithSealPolicy(ioc.SealPanic), ioc.SealAfterBuild)
+	var err error
+	func() {
+		defer ioc.Recover(&err)
+		ioc.TryGet[Repo](c)
+	}()
+	if !errors.Is(err, ioc.ErrSealed) {
+		t.Errorf("expected panic with ErrSealed and got %v", err)
+	}
+}
+
+func TestSealCreatorScope(t *testing.T) {
+	type Repo struct{}
+	type Slow struct{}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	c := ioc.NewContainer(ioc.LazyConstruction, func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Repo { return Repo{} })
+		ioc.Register(b, func(c ioc.Dic) Slow {
+			close(started)
+			<-release
+			ioc.Get[Repo](c)
+			return Slow{}
+		})
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := ioc.TryGet[Slow](c)
+		done <- err
+	}()
+	<-started
+	c.Seal()
+	if _, err := ioc.TryGet[Repo](c); !errors.Is(err, ioc.ErrSealed) {
+		t.Errorf("expected retrieval outside of creator to be reported while other service is created and got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("expected retrieval of creator not to be reported and got %v", err)
+	}
+
+	if violations := c.SealViolations(); len(violations) != 1 {
+		t.Errorf("expected only retrieval outside of creator to be reported and got %v", violations)
+	}
+}
+
+func TestSealTransient(t *testing.T) {
+	type Repo struct{}
+	type Handler struct {
+		c    ioc.Dic
+		Repo Repo
+	}
+	type Services struct {
+		Handlers ioc.Transient[Handler] `inject:""`
+	}
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Repo { return Repo{} })
+		ioc.Register(b, func(c ioc.Dic) Handler { return Handler{c: c, Repo: ioc.Get[Repo](c)} })
+	})
+	services := ioc.GetServices[Services](c)
+	c.Seal()
+
+	handler := services.Handlers()
+	if violations := c.SealViolations(); len(violations) != 0 {
+		t.Errorf("expected transient creator not to be reported and got %v", violations)
+	}
+	if _, err := ioc.TryGet[Repo](handler.c); !errors.Is(err, ioc.ErrSealed) {
+		t.Errorf("expected container kept by transient instance to be sealed and got %v", err)
+	}
+}
+
+func TestSealFactory(t *testing.T) {
+	type Repo struct{}
+	type Handler struct {
+		c    ioc.Dic
+		Repo Repo
+	}
+
+	c := ioc.NewContainer(func(b ioc.Builder) {
+		ioc.Register(b, func(c ioc.Dic) Repo { return Repo{} })
+		ioc.RegisterFactory(b, func(c ioc.Dic, id string) *Handler { return &Handler{c: c, Repo: ioc.Get[Repo](c)} })
+	})
+	handlers := ioc.Get[ioc.Factory[string, *Handler]](c)
+	c.Seal()
+
+	handler := handlers("id")
+	if violations := c.SealViolations(); len(violations) != 0 {
+		t.Errorf("expected factory ctor not to be reported and got %v", violations)
+	}
+	if _, err := ioc.TryGet[Repo](handler.c); !errors.Is(err, ioc.ErrSealed) {
+		t.Errorf("expected container kept by factory product to be sealed and got %v", err)
+	}
+}
@@ -1,25 +1,75 @@
 package ioc
 
+import "sync/atomic"
+
+// serviceState is separate from instance because nil is a valid service.
+// State is stored atomically after instance so services read without lock see created instance
+type serviceState = uint32
+
+const (
+	serviceNotCreated serviceState = iota
+	serviceCreated
+	// serviceFailed is set when creator panicked and failure is cached
+	serviceFailed
+)
+
 type service struct {
 	creator  func(Dic) any
 	wraps    func(Dic, any)
 	instance *any
+	state    *atomic.Uint32
+	// err is a cause of failed construction
+	err *error
+	// owner is a resolution creating the service. It is guarded by creationMapMutex
+	owner **resolution
+	// creating is set while creator is called
+	creating *atomic.Bool
+
+	// module which registered the service
+	module string
+	// private service can be resolved only by services of the same module
+	private bool
+}
+
+// serviceData is allocated once per service and shared by service copies
+type serviceData struct {
+	instance any
+	state    atomic.Uint32
+	err      error
+	owner    *resolution
+	creating atomic.Bool
 }
 
 func newService(creator func(Dic) any) service {
-	var instance any
+	data := &serviceData{}
 	return service{
 		creator:  creator,
-		wraps:    func(d Dic, a any) {},
-		instance: &instance,
+		wraps:    noWraps,
+		instance: &data.instance,
+		state:    &data.state,
+		err:      &data.err,
+		owner:    &data.owner,
+		creating: &data.creating,
 	}
 }
 
+// created reports whether instance is created
+func (s service) created() bool {
+	return s.state.Load() == serviceCreated
+}
+
+func noWraps(Dic, any) {}
+
 type ctorWrap struct {
 	wraps func(c Dic, s any)
+	// module which registered the wrap
+	module string
 }
 
 func newCtorWrap[T any](wrap func(c Dic, s T)) ctorWrap {
 	w := wrap
-	return ctorWrap{wraps: func(c Dic, s any) { w(c, s.(T)) }}
+	return ctorWrap{wraps: func(c Dic, s any) {
+		service, _ := s.(T) // nil interfaces are valid services
+		w(c, service)
+	}}
 }